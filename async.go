@@ -0,0 +1,236 @@
+package sentryzapcore
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultAsyncQueueSize is the queue size used when WithAsync is given a
+	// size of zero or less.
+	defaultAsyncQueueSize = 1024
+	// asyncBatchSize is the maximum number of entries a worker takes from the
+	// queue at once.
+	asyncBatchSize = 64
+)
+
+// OverflowPolicy selects what an asynchronous core does with an entry when
+// its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the logging goroutine until there is room.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the entry being logged.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest queued entry to make room.
+	OverflowDropOldest
+)
+
+// AsyncStats reports the state of the queue of an asynchronous core.
+type AsyncStats struct {
+	Queued        int    // entries waiting in the queue
+	DroppedNewest uint64 // entries dropped by OverflowDropNewest
+	DroppedOldest uint64 // entries dropped by OverflowDropOldest
+}
+
+// asyncEntry is an entry queued with the core it was logged through.
+type asyncEntry struct {
+	core   *SentryCore
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+// asyncWriter is a bounded queue of entries written to Sentry by worker
+// goroutines, in batches. It is shared by the cores derived via With.
+type asyncWriter struct {
+	policy  OverflowPolicy
+	workers int
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	done     *sync.Cond // signaled when entries are written or dropped
+
+	queue     []asyncEntry // ring buffer
+	head      int
+	size      int
+	enqueued  uint64 // entries accepted into the queue
+	completed uint64 // accepted entries written or dropped
+	stats     AsyncStats
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+func newAsyncWriter(queueSize, workers int, policy OverflowPolicy) *asyncWriter {
+	if queueSize <= 0 {
+		queueSize = defaultAsyncQueueSize
+	}
+
+	if workers <= 0 {
+		workers = 1
+	}
+
+	w := &asyncWriter{
+		policy:  policy,
+		workers: workers,
+		queue:   make([]asyncEntry, queueSize),
+	}
+
+	w.notEmpty = sync.NewCond(&w.mu)
+	w.notFull = sync.NewCond(&w.mu)
+	w.done = sync.NewCond(&w.mu)
+
+	return w
+}
+
+// start starts the worker goroutines.
+func (w *asyncWriter) start() {
+	for range w.workers {
+		w.wg.Go(w.work)
+	}
+}
+
+// enqueue queues the entry, applying the overflow policy when the queue is
+// full. It returns false, for the caller to write the entry itself, when the
+// writer is nil or closed.
+func (w *asyncWriter) enqueue(e asyncEntry) bool {
+	if w == nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.size == len(w.queue) && !w.closed {
+		switch w.policy {
+		case OverflowDropNewest:
+			w.stats.DroppedNewest++
+			return true
+		case OverflowDropOldest:
+			w.queue[w.head] = asyncEntry{}
+			w.head = (w.head + 1) % len(w.queue)
+			w.size--
+			w.completed++
+			w.stats.DroppedOldest++
+			w.done.Broadcast()
+		default:
+			w.notFull.Wait()
+		}
+	}
+
+	if w.closed {
+		return false
+	}
+
+	w.queue[(w.head+w.size)%len(w.queue)] = e
+	w.size++
+	w.enqueued++
+	w.notEmpty.Signal()
+
+	return true
+}
+
+// work writes batches of queued entries until the writer is closed and the
+// queue is empty. Entries of a batch are written in queue order.
+func (w *asyncWriter) work() {
+	batch := make([]asyncEntry, 0, asyncBatchSize)
+
+	for {
+		w.mu.Lock()
+
+		for w.size == 0 && !w.closed {
+			w.notEmpty.Wait()
+		}
+
+		if w.size == 0 {
+			w.mu.Unlock()
+			return
+		}
+
+		for w.size > 0 && len(batch) < asyncBatchSize {
+			batch = append(batch, w.queue[w.head])
+			w.queue[w.head] = asyncEntry{}
+			w.head = (w.head + 1) % len(w.queue)
+			w.size--
+		}
+
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		for _, e := range batch {
+			_ = e.core.write(e.entry, e.fields)
+		}
+
+		w.mu.Lock()
+		w.completed += uint64(len(batch))
+		w.done.Broadcast()
+		w.mu.Unlock()
+
+		clear(batch)
+		batch = batch[:0]
+	}
+}
+
+// drain waits until the entries queued before the call are written or
+// dropped, or until ctx is done. It returns how many of them are left.
+func (w *asyncWriter) drain(ctx context.Context) int {
+	if w == nil {
+		return 0
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		w.done.Broadcast()
+	})
+	defer stop()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	target := w.enqueued
+	for w.completed < target && ctx.Err() == nil {
+		w.done.Wait()
+	}
+
+	if w.completed >= target {
+		return 0
+	}
+
+	return int(target - w.completed)
+}
+
+// close drains the queue and stops the workers. Later entries are written
+// synchronously.
+func (w *asyncWriter) close() {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	w.closed = true
+	w.notEmpty.Broadcast()
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	w.wg.Wait()
+}
+
+// snapshot returns the current stats.
+func (w *asyncWriter) snapshot() AsyncStats {
+	if w == nil {
+		return AsyncStats{}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := w.stats
+	stats.Queued = w.size
+
+	return stats
+}
@@ -0,0 +1,199 @@
+package sentryzapcore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logBodies returns the bodies of the logs sent so far, in order.
+func logBodies(events []*sentry.Event) []string {
+	var bodies []string
+
+	for _, event := range events {
+		for _, log := range event.Logs {
+			bodies = append(bodies, log.Body)
+		}
+	}
+
+	return bodies
+}
+
+func TestAsyncOrdering(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithAsync(16, 1, OverflowBlock))
+	t.Cleanup(func() { _ = core.Close() })
+
+	logger := zap.New(core)
+	prefix := gofakeit.UUID()
+
+	// Stay below the 100 logs the SDK buffers before it drops the oldest,
+	// while still overflowing the queue.
+	want := make([]string, 80)
+	for i := range want {
+		want[i] = fmt.Sprintf("%s %d", prefix, i)
+		logger.Error(want[i], zap.Int("i", i))
+	}
+
+	require.NoError(t, logger.Sync())
+	require.Zero(t, core.AsyncStats().Queued)
+	require.Equal(t, want, logBodies(transport.Events()))
+}
+
+// blockingHook holds the worker in WithBeforeEmit until released.
+type blockingHook struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newBlockingHook() *blockingHook {
+	return &blockingHook{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (h *blockingHook) hook(entry zapcore.Entry, attrs map[string]interface{}) (zapcore.Entry, map[string]interface{}, bool) {
+	h.once.Do(func() { close(h.started) })
+	<-h.release
+
+	return entry, attrs, true
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		policy OverflowPolicy
+		want   []string
+		stats  AsyncStats
+	}{
+		{
+			name:   "drop newest",
+			policy: OverflowDropNewest,
+			want:   []string{"0", "1", "2"},
+			stats:  AsyncStats{DroppedNewest: 2},
+		},
+		{
+			name:   "drop oldest",
+			policy: OverflowDropOldest,
+			want:   []string{"0", "3", "4"},
+			stats:  AsyncStats{DroppedOldest: 2},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &transportMock{}
+			hub := newTestHub(t, transport)
+			hook := newBlockingHook()
+
+			core := NewSentryCore(context.Background(),
+				WithHub(hub),
+				WithBeforeEmit(hook.hook),
+				WithAsync(2, 1, tt.policy),
+			)
+			logger := zap.New(core)
+
+			// The worker holds "0" while the queue fills up.
+			logger.Error("0")
+			<-hook.started
+
+			for i := 1; i < 5; i++ {
+				logger.Error(fmt.Sprint(i))
+			}
+
+			require.Equal(t, AsyncStats{Queued: 2, DroppedNewest: tt.stats.DroppedNewest, DroppedOldest: tt.stats.DroppedOldest}, core.AsyncStats())
+
+			close(hook.release)
+			require.NoError(t, core.Close())
+
+			require.Equal(t, tt.stats, core.AsyncStats())
+			require.Equal(t, tt.want, logBodies(transport.Events()))
+		})
+	}
+}
+
+func TestAsyncBlock(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	hook := newBlockingHook()
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithBeforeEmit(hook.hook), WithAsync(1, 1, OverflowBlock))
+	logger := zap.New(core)
+
+	logger.Error("0")
+	<-hook.started
+	logger.Error("1")
+
+	written := make(chan struct{})
+
+	go func() {
+		logger.Error("2")
+		close(written)
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("write did not block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(hook.release)
+	<-written
+
+	require.NoError(t, core.Close())
+	require.Equal(t, []string{"0", "1", "2"}, logBodies(transport.Events()))
+	require.Equal(t, AsyncStats{}, core.AsyncStats())
+}
+
+func TestAsyncShutdown(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithAsync(0, 4, OverflowBlock))
+	logger := zap.New(core).With(zap.String("component", "worker"))
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 10 {
+				logger.Error(fmt.Sprintf("%d-%d", g, i))
+			}
+		})
+	}
+
+	wg.Wait()
+	require.NoError(t, core.Close())
+	require.Len(t, logBodies(transport.Events()), 80)
+
+	// After Close, entries are written synchronously.
+	message := gofakeit.Sentence()
+	logger.Error(message)
+	hub.Flush(time.Second)
+
+	_, found := findLog(transport.Events(), message)
+	require.True(t, found)
+	require.NoError(t, core.Close())
+}
+
+func TestAsyncDPanicIsWrittenAfterQueue(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithAsync(16, 1, OverflowBlock))
+	t.Cleanup(func() { _ = core.Close() })
+
+	logger := zap.New(core)
+
+	logger.Error("queued")
+	logger.DPanic("dpanic")
+	hub.Flush(time.Second)
+
+	require.Equal(t, []string{"queued", "dpanic"}, logBodies(transport.Events()))
+}
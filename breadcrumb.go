@@ -0,0 +1,66 @@
+package sentryzapcore
+
+import (
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zapcore"
+)
+
+// breadcrumbEnabled reports whether entries at the given level are recorded
+// as breadcrumbs.
+func (s *SentryCore) breadcrumbEnabled(level zapcore.Level) bool {
+	return s.breadcrumbLevel != nil && s.breadcrumbLevel.Enabled(level)
+}
+
+// addBreadcrumb records the entry as a breadcrumb on the scope of the hub
+// resolved for the entry, so that a later event carries it. The client's
+// BeforeBreadcrumb callback is honored.
+func (s *SentryCore) addBreadcrumb(entry zapcore.Entry, encoded encodedFields) {
+	hub := s.hubFor(encoded.ctx)
+	breadcrumb := s.breadcrumbFromEntry(entry, encoded)
+
+	if s.maxBreadcrumbs <= 0 {
+		hub.AddBreadcrumb(breadcrumb, nil)
+		return
+	}
+
+	if client := hub.Client(); client != nil {
+		if before := client.Options().BeforeBreadcrumb; before != nil {
+			if breadcrumb = before(breadcrumb, &sentry.BreadcrumbHint{}); breadcrumb == nil {
+				return
+			}
+		}
+	}
+
+	hub.Scope().AddBreadcrumb(breadcrumb, s.maxBreadcrumbs)
+}
+
+// breadcrumbFromEntry converts a zap entry and its encoded fields into a
+// sentry.Breadcrumb. Core attributes (from With) and entry fields become the
+// breadcrumb data, converted the same way as log attributes.
+func (s *SentryCore) breadcrumbFromEntry(entry zapcore.Entry, encoded encodedFields) *sentry.Breadcrumb {
+	breadcrumb := &sentry.Breadcrumb{
+		Category:  entry.LoggerName,
+		Message:   entry.Message,
+		Level:     s.sentryLevel(entry.Level),
+		Timestamp: entry.Time,
+	}
+
+	if encoded.fields.level != "" {
+		breadcrumb.Level = encoded.fields.level
+	}
+
+	if len(s.attributes) == 0 && len(encoded.values) == 0 {
+		return breadcrumb
+	}
+
+	breadcrumb.Data = make(map[string]interface{}, len(s.attributes)+len(encoded.values))
+	for _, attr := range s.attributes {
+		breadcrumb.Data[attr.Key] = attr.Value.AsInterface()
+	}
+
+	for _, attr := range attributesFromValues(encoded.values) {
+		breadcrumb.Data[attr.Key] = attr.Value.AsInterface()
+	}
+
+	return breadcrumb
+}
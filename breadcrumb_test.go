@@ -0,0 +1,79 @@
+package sentryzapcore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestBreadcrumbs(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	core := NewSentryCore(ctx, WithEventLevel(zapcore.ErrorLevel), WithBreadcrumbs(zapcore.DebugLevel, 2))
+	logger := zap.New(core).Named("checkout")
+
+	logger.Debug(gofakeit.Sentence())
+	logger.Info("loading cart", zap.Int("items", 3))
+	logger.Warn("slow response", zap.Duration("elapsed", time.Second))
+
+	message := gofakeit.Sentence()
+	logger.Error(message)
+	hub.Flush(2 * time.Second)
+
+	event, found := findEvent(transport.Events(), message)
+	require.True(t, found)
+	require.Len(t, event.Breadcrumbs, 2)
+
+	info := event.Breadcrumbs[0]
+	require.Equal(t, "loading cart", info.Message)
+	require.Equal(t, "checkout", info.Category)
+	require.Equal(t, sentry.LevelInfo, info.Level)
+	require.Equal(t, int64(3), info.Data["items"])
+
+	warn := event.Breadcrumbs[1]
+	require.Equal(t, "slow response", warn.Message)
+	require.Equal(t, sentry.LevelWarning, warn.Level)
+	require.Equal(t, time.Second.String(), warn.Data["elapsed"])
+
+	_, found = findLog(transport.Events(), "loading cart")
+	require.False(t, found)
+}
+
+func TestBreadcrumbsBeforeBreadcrumb(t *testing.T) {
+	transport := &transportMock{}
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Transport: transport,
+		BeforeBreadcrumb: func(breadcrumb *sentry.Breadcrumb, _ *sentry.BreadcrumbHint) *sentry.Breadcrumb {
+			if breadcrumb.Message == "drop me" {
+				return nil
+			}
+
+			return breadcrumb
+		},
+	})
+	require.NoError(t, err)
+
+	hub := sentry.NewHub(client, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	logger := zap.New(NewSentryCore(ctx, WithEventLevel(zapcore.ErrorLevel), WithBreadcrumbs(zapcore.InfoLevel, 10)))
+	logger.Info("drop me")
+	logger.Info("keep me")
+	logger.Debug("below breadcrumb level")
+
+	message := gofakeit.Sentence()
+	logger.Error(message)
+
+	event, found := findEvent(transport.Events(), message)
+	require.True(t, found)
+	require.Len(t, event.Breadcrumbs, 1)
+	require.Equal(t, "keep me", event.Breadcrumbs[0].Message)
+}
@@ -0,0 +1,390 @@
+package sentryzapcore
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zapcore"
+)
+
+// Ensure TraceBufferCore implements zapcore.Core interface.
+var _ zapcore.Core = (*TraceBufferCore)(nil)
+
+const (
+	// defaultBufferPerTrace is the default number of entries kept per trace.
+	defaultBufferPerTrace = 100
+	// defaultBufferTotal is the default number of entries kept over all traces.
+	defaultBufferTotal = 10000
+	// defaultBufferTTL is the default time a trace is kept after its first
+	// entry.
+	defaultBufferTTL = time.Minute
+)
+
+// TraceBufferOption is a functional option for configuring a TraceBufferCore.
+type TraceBufferOption func(*traceBuffer)
+
+// TraceBufferCore is a zapcore.Core that holds back entries below a trigger
+// level, per trace, and writes them to the wrapped core only if an entry at
+// or above the trigger level is logged in the same trace. This ships the full
+// detail of failed requests without ingesting every successful one.
+//
+// The trace of an entry is the one of the span carried by its context field
+// (see Context). Entries below the trigger level without a span are dropped.
+// A trace is forgotten, along with its held entries, when its context is done
+// (for example when the HTTP request it belongs to completes), when its TTL
+// expires or when the global limit is reached. Call FinishTrace when the
+// transaction finishes, before its context is done, so that a trigger entry
+// logged after it does not release the held entries. The fields of a
+// sentry.Span are not read, since Span.Finish writes them without a lock.
+type TraceBufferCore struct {
+	core   zapcore.Core
+	ctx    context.Context // context added via With, if any
+	buffer *traceBuffer    // shared by the cores derived via With
+}
+
+// NewTraceBufferCore creates a TraceBufferCore that writes to core, usually
+// a *SentryCore. Held entries are written to core as is, so core must enable
+// their levels, for example with WithMinLevel(zapcore.DebugLevel). By
+// default, Debug and Info entries are held until an Error entry, up to 100
+// entries per trace and 10000 in total, for at most a minute.
+func NewTraceBufferCore(core zapcore.Core, options ...TraceBufferOption) *TraceBufferCore {
+	b := &traceBuffer{
+		triggerLevel: zapcore.ErrorLevel,
+		bufferLevel:  zapcore.DebugLevel,
+		maxPerTrace:  defaultBufferPerTrace,
+		maxTotal:     defaultBufferTotal,
+		ttl:          defaultBufferTTL,
+		now:          time.Now,
+		traces:       make(map[sentry.TraceID]*bufferedTrace),
+		order:        list.New(),
+	}
+
+	for _, opt := range options {
+		opt(b)
+	}
+
+	return &TraceBufferCore{core: core, buffer: b}
+}
+
+// WithTriggerLevel sets the level at or above which an entry releases the
+// held entries of its trace. It defaults to Error.
+func WithTriggerLevel(level zapcore.Level) TraceBufferOption {
+	return func(b *traceBuffer) {
+		b.triggerLevel = level
+	}
+}
+
+// WithBufferLevel sets the levels below the trigger level that are held.
+// Other entries below the trigger level are dropped. It defaults to Debug.
+func WithBufferLevel(level zapcore.LevelEnabler) TraceBufferOption {
+	return func(b *traceBuffer) {
+		b.bufferLevel = level
+	}
+}
+
+// WithBufferLimits caps the entries held for a single trace, dropping its
+// oldest entry first, and over all traces, dropping the oldest trace first.
+func WithBufferLimits(perTrace, total int) TraceBufferOption {
+	return func(b *traceBuffer) {
+		b.maxPerTrace = perTrace
+		b.maxTotal = total
+	}
+}
+
+// WithBufferTTL sets how long a trace is kept after its first entry.
+func WithBufferTTL(ttl time.Duration) TraceBufferOption {
+	return func(b *traceBuffer) {
+		b.ttl = ttl
+	}
+}
+
+// Enabled reports whether the core handles the given level: the wrapped
+// core decides at or above the trigger level, the buffer level below it.
+// It implements the zapcore.LevelEnabler interface.
+func (c *TraceBufferCore) Enabled(level zapcore.Level) bool {
+	if level >= c.buffer.triggerLevel {
+		return c.core.Enabled(level)
+	}
+
+	return c.buffer.bufferLevel.Enabled(level)
+}
+
+// With adds structured context to the wrapped core and remembers a context
+// field for the trace lookup.
+// It implements the zapcore.Core interface.
+func (c *TraceBufferCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &TraceBufferCore{
+		core:   c.core.With(fields),
+		ctx:    c.ctx,
+		buffer: c.buffer,
+	}
+
+	if ctx := contextFromFields(fields); ctx != nil {
+		clone.ctx = ctx
+	}
+
+	return clone
+}
+
+// Check determines whether the supplied Entry should be logged.
+// It implements the zapcore.Core interface.
+func (c *TraceBufferCore) Check(entry zapcore.Entry, checkEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkEntry.AddCore(entry, c)
+	}
+
+	return checkEntry
+}
+
+// Write holds an entry below the trigger level until its trace is
+// triggered. An entry at or above the trigger level is written after the
+// held entries of its trace, and from then on the trace's entries are
+// written as they come.
+// It implements the zapcore.Core interface.
+func (c *TraceBufferCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	ctx := contextFromFields(fields)
+	if ctx == nil {
+		ctx = c.ctx
+	}
+
+	var span *sentry.Span
+	if ctx != nil {
+		span = sentry.SpanFromContext(ctx)
+	}
+
+	if entry.Level >= c.buffer.triggerLevel {
+		var errs []error
+
+		if span != nil {
+			for _, held := range c.buffer.trigger(span, ctx) {
+				errs = append(errs, held.core.Write(held.entry, held.fields))
+			}
+		}
+
+		errs = append(errs, c.core.Write(entry, fields))
+
+		return errors.Join(errs...)
+	}
+
+	if span == nil {
+		return nil
+	}
+
+	held := bufferedEntry{
+		core:   c.core,
+		entry:  entry,
+		fields: append([]zapcore.Field(nil), fields...),
+	}
+
+	if c.buffer.hold(span, ctx, held) {
+		return nil
+	}
+
+	return c.core.Write(entry, fields)
+}
+
+// FinishTrace drops the held entries of the trace of the span carried by ctx.
+// From then on, until its context is done or its TTL expires, the trace's
+// entries below the trigger level are dropped and entries at or above it
+// release nothing. Call it when the transaction of the trace finishes, for example
+// with defer right after starting it:
+//
+//	transaction := sentry.StartTransaction(ctx, "request")
+//	defer transaction.Finish()
+//	defer core.FinishTrace(transaction.Context())
+//
+// It does nothing when ctx carries no span.
+func (c *TraceBufferCore) FinishTrace(ctx context.Context) {
+	span := sentry.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+
+	c.buffer.finish(span, ctx)
+}
+
+// Sync flushes the wrapped core. Held entries are not written.
+// It implements the zapcore.Core interface.
+func (c *TraceBufferCore) Sync() error {
+	return c.core.Sync()
+}
+
+// traceBuffer holds the entries of every trace. It is shared by the cores
+// derived via With.
+type traceBuffer struct {
+	triggerLevel zapcore.Level
+	bufferLevel  zapcore.LevelEnabler
+	maxPerTrace  int
+	maxTotal     int
+	ttl          time.Duration
+	now          func() time.Time
+
+	mu     sync.Mutex
+	traces map[sentry.TraceID]*bufferedTrace
+	order  *list.List // of *bufferedTrace, oldest first
+	total  int        // entries held over all traces
+}
+
+// bufferedTrace is the state of a single trace.
+type bufferedTrace struct {
+	id        sentry.TraceID
+	created   time.Time
+	entries   []bufferedEntry
+	triggered bool          // entries are written as they come
+	finished  bool          // FinishTrace was called; entries are dropped
+	element   *list.Element // position in traceBuffer.order
+	stop      func() bool   // stops the removal on context done
+}
+
+// bufferedEntry is an entry held for a trace, with the core it was logged
+// through.
+type bufferedEntry struct {
+	core   zapcore.Core
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+// hold keeps the entry for the trace of the span. It returns false, without
+// keeping the entry, when the trace has already been triggered. The entry is
+// dropped when the trace is finished.
+func (b *traceBuffer) hold(span *sentry.Span, ctx context.Context, held bufferedEntry) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+
+	t := b.lookup(span, ctx)
+	if t.finished {
+		return true
+	}
+
+	if t.triggered {
+		return false
+	}
+
+	if b.maxPerTrace <= 0 {
+		return true
+	}
+
+	if len(t.entries) >= b.maxPerTrace {
+		copy(t.entries, t.entries[1:])
+		t.entries = t.entries[:len(t.entries)-1]
+		b.total--
+	}
+
+	t.entries = append(t.entries, held)
+	b.total++
+
+	for b.total > b.maxTotal && b.order.Len() > 0 {
+		b.evict(b.order.Front().Value.(*bufferedTrace))
+	}
+
+	return true
+}
+
+// trigger marks the trace of the span as triggered and returns its held
+// entries. A finished trace returns none.
+func (b *traceBuffer) trigger(span *sentry.Span, ctx context.Context) []bufferedEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+
+	t := b.lookup(span, ctx)
+	if t.finished {
+		return nil
+	}
+
+	entries := t.entries
+
+	t.triggered = true
+	t.entries = nil
+	b.total -= len(entries)
+
+	return entries
+}
+
+// lookup returns the trace of the span, creating it if needed. A new trace
+// is removed once ctx is done. b.mu must be held.
+func (b *traceBuffer) lookup(span *sentry.Span, ctx context.Context) *bufferedTrace {
+	if t, ok := b.traces[span.TraceID]; ok {
+		return t
+	}
+
+	t := &bufferedTrace{id: span.TraceID, created: b.now()}
+	t.element = b.order.PushBack(t)
+	b.traces[span.TraceID] = t
+
+	if ctx.Done() != nil {
+		t.stop = context.AfterFunc(ctx, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			b.evict(t)
+		})
+	}
+
+	return t
+}
+
+// expire evicts the traces older than the TTL. b.mu must be held.
+func (b *traceBuffer) expire() {
+	now := b.now()
+
+	for front := b.order.Front(); front != nil; front = b.order.Front() {
+		t := front.Value.(*bufferedTrace)
+		if now.Sub(t.created) < b.ttl {
+			break
+		}
+
+		b.evict(t)
+	}
+}
+
+// finish marks the trace of the span as finished and drops its held
+// entries.
+func (b *traceBuffer) finish(span *sentry.Span, ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+
+	t := b.lookup(span, ctx)
+	t.finished = true
+	b.total -= len(t.entries)
+	t.entries = nil
+}
+
+// evict forgets the trace and drops its held entries. b.mu must be held.
+func (b *traceBuffer) evict(t *bufferedTrace) {
+	if b.traces[t.id] != t {
+		return
+	}
+
+	delete(b.traces, t.id)
+	b.order.Remove(t.element)
+	b.total -= len(t.entries)
+
+	if t.stop != nil {
+		t.stop()
+	}
+}
+
+// contextFromFields returns the context carried by a context field, if any.
+func contextFromFields(fields []zapcore.Field) context.Context {
+	for _, f := range fields {
+		if f.Type != zapcore.SkipType {
+			continue
+		}
+
+		if ctx, ok := f.Interface.(context.Context); ok && ctx != nil {
+			return ctx
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package sentryzapcore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func messages(logs *observer.ObservedLogs) []string {
+	entries := logs.TakeAll()
+
+	result := make([]string, len(entries))
+	for i, entry := range entries {
+		result[i] = entry.Message
+	}
+
+	return result
+}
+
+func heldEntries(core *TraceBufferCore) int {
+	core.buffer.mu.Lock()
+	defer core.buffer.mu.Unlock()
+
+	return core.buffer.total
+}
+
+func TestTraceBufferCore(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	sentryCore := NewSentryCore(ctx, WithMinLevel(zapcore.DebugLevel))
+	bufferCore := NewTraceBufferCore(sentryCore)
+	logger := zap.New(bufferCore)
+
+	t.Run("failed trace is shipped", func(t *testing.T) {
+		span := sentry.StartSpan(ctx, "request")
+		defer span.Finish()
+
+		requestLogger := logger.With(Context(span.Context()))
+
+		debug := gofakeit.Sentence()
+		requestLogger.Debug(debug)
+		hub.Flush(2 * time.Second)
+
+		_, found := findLog(transport.Events(), debug)
+		require.False(t, found)
+
+		failure := gofakeit.Sentence()
+		requestLogger.Error(failure)
+
+		after := gofakeit.Sentence()
+		requestLogger.Info(after)
+		hub.Flush(2 * time.Second)
+
+		for _, message := range []string{debug, failure, after} {
+			logEntry, found := findLog(transport.Events(), message)
+			require.True(t, found, message)
+			require.Equal(t, span.TraceID, logEntry.TraceID)
+		}
+	})
+
+	t.Run("successful trace is dropped", func(t *testing.T) {
+		span := sentry.StartSpan(ctx, "request")
+
+		info := gofakeit.Sentence()
+		logger.Info(info, Context(span.Context()))
+
+		bufferCore.FinishTrace(span.Context())
+		span.Finish()
+
+		late := gofakeit.Sentence()
+		logger.Info(late, Context(span.Context()))
+
+		failure := gofakeit.Sentence()
+		logger.Error(failure, Context(span.Context()))
+		hub.Flush(2 * time.Second)
+
+		require.Zero(t, heldEntries(bufferCore))
+
+		for _, message := range []string{info, late} {
+			_, found := findLog(transport.Events(), message)
+			require.False(t, found, message)
+		}
+
+		_, found := findLog(transport.Events(), failure)
+		require.True(t, found)
+	})
+
+	t.Run("finished child span", func(t *testing.T) {
+		transaction := sentry.StartSpan(ctx, "request")
+		defer transaction.Finish()
+
+		child := transaction.StartChild("query")
+
+		debug := gofakeit.Sentence()
+		logger.Debug(debug, Context(child.Context()))
+		child.Finish()
+
+		logger.Error(gofakeit.Sentence(), Context(transaction.Context()))
+		hub.Flush(2 * time.Second)
+
+		_, found := findLog(transport.Events(), debug)
+		require.True(t, found)
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		requestCtx, cancel := context.WithCancel(ctx)
+		span := sentry.StartSpan(requestCtx, "request")
+		defer span.Finish()
+
+		logger.Info(gofakeit.Sentence(), Context(span.Context()))
+		cancel()
+
+		require.Eventually(t, func() bool { return heldEntries(bufferCore) == 0 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("transaction finished concurrently", func(t *testing.T) {
+		requestCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		transaction := sentry.StartTransaction(requestCtx, "request")
+		spanCtx := Context(transaction.Context())
+
+		started := make(chan struct{})
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			logger.Info("first", spanCtx)
+			close(started)
+
+			for range 100 {
+				logger.Info("next", spanCtx)
+			}
+		}()
+
+		<-started
+		transaction.Finish()
+		<-done
+	})
+
+	t.Run("entries without a trace", func(t *testing.T) {
+		info := gofakeit.Sentence()
+		logger.Info(info)
+
+		failure := gofakeit.Sentence()
+		logger.Error(failure)
+		hub.Flush(2 * time.Second)
+
+		_, found := findLog(transport.Events(), info)
+		require.False(t, found)
+
+		_, found = findLog(transport.Events(), failure)
+		require.True(t, found)
+	})
+}
+
+func TestTraceBufferLimits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("per trace", func(t *testing.T) {
+		observed, logs := observer.New(zapcore.DebugLevel)
+		logger := zap.New(NewTraceBufferCore(observed, WithBufferLimits(2, 10)))
+		spanCtx := Context(sentry.StartSpan(ctx, "request").Context())
+
+		logger.Debug("one", spanCtx)
+		logger.Debug("two", spanCtx)
+		logger.Debug("three", spanCtx)
+		logger.Warn("four", spanCtx)
+		require.Empty(t, logs.All())
+
+		logger.Error("five", spanCtx)
+		require.Equal(t, []string{"three", "four", "five"}, messages(logs))
+	})
+
+	t.Run("total", func(t *testing.T) {
+		observed, logs := observer.New(zapcore.DebugLevel)
+		logger := zap.New(NewTraceBufferCore(observed, WithBufferLimits(10, 2)))
+		first := Context(sentry.StartSpan(ctx, "first").Context())
+		second := Context(sentry.StartSpan(ctx, "second").Context())
+
+		logger.Info("first", first)
+		logger.Info("second", second)
+		logger.Info("second again", second)
+
+		logger.Error("first failed", first)
+		require.Equal(t, []string{"first failed"}, messages(logs))
+
+		logger.Error("second failed", second)
+		require.Equal(t, []string{"second", "second again", "second failed"}, messages(logs))
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		observed, logs := observer.New(zapcore.DebugLevel)
+		core := NewTraceBufferCore(observed, WithBufferTTL(time.Minute), WithTriggerLevel(zapcore.WarnLevel))
+		logger := zap.New(core)
+		spanCtx := Context(sentry.StartSpan(ctx, "request").Context())
+
+		now := time.Now()
+		core.buffer.now = func() time.Time { return now }
+
+		logger.Info("stale", spanCtx)
+
+		now = now.Add(time.Minute)
+
+		logger.Warn("warn", spanCtx)
+		require.Equal(t, []string{"warn"}, messages(logs))
+	})
+
+	t.Run("buffer level", func(t *testing.T) {
+		observed, logs := observer.New(zapcore.DebugLevel)
+		logger := zap.New(NewTraceBufferCore(observed, WithBufferLevel(zapcore.InfoLevel)))
+		spanCtx := Context(sentry.StartSpan(ctx, "request").Context())
+
+		require.False(t, logger.Core().Enabled(zapcore.DebugLevel))
+
+		logger.Debug("debug", spanCtx)
+		logger.Info("info", spanCtx)
+		logger.Error("error", spanCtx)
+		require.Equal(t, []string{"info", "error"}, messages(logs))
+	})
+}
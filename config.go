@@ -0,0 +1,678 @@
+package sentryzapcore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/getsentry/sentry-go/attribute"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnvPrefix is the prefix of the environment variables read by
+// LoadConfigEnv.
+const ConfigEnvPrefix = "SENTRY_ZAP_"
+
+// Config describes the options of a SentryCore in a form that can be loaded
+// from JSON, YAML or the environment, so that services can share one
+// configuration shape. Zero values keep the defaults of the options. Build
+// turns it into options.
+//
+// Durations are strings such as "5s" and levels are zap level names. With
+// LoadConfigEnv, each key is read from ConfigEnvPrefix followed by its upper
+// case path, such as SENTRY_ZAP_RATE_LIMIT_PER_SECOND; lists are comma
+// separated, and maps are comma-separated key=value pairs. Routes cannot be
+// read from the environment.
+type Config struct {
+	// Level is the minimum level of structured logs; see WithMinLevel.
+	Level string `json:"level" yaml:"level"`
+	// EventLevel enables event capture; see WithEventLevel.
+	EventLevel string `json:"event_level" yaml:"event_level"`
+	// BreadcrumbLevel and MaxBreadcrumbs enable breadcrumbs; see
+	// WithBreadcrumbs.
+	BreadcrumbLevel string `json:"breadcrumb_level" yaml:"breadcrumb_level"`
+	MaxBreadcrumbs  int    `json:"max_breadcrumbs" yaml:"max_breadcrumbs"`
+	// Loggers maps logger name prefixes to levels; see WithLoggerLevels.
+	Loggers map[string]string `json:"loggers" yaml:"loggers"`
+	// LevelMapping maps zap level names, or numbers for custom levels, to
+	// Sentry log severities; see WithLevelMapper. Unmapped levels keep the
+	// default severity.
+	LevelMapping map[string]string `json:"level_mapping" yaml:"level_mapping"`
+
+	// StackTrace enables stack traces; see WithStackTrace.
+	StackTrace bool `json:"stacktrace" yaml:"stacktrace"`
+	// InAppPrefixes sets the in-app modules; see WithInAppPrefixes.
+	InAppPrefixes []string `json:"in_app_prefixes" yaml:"in_app_prefixes"`
+
+	// Attributes are added to every entry; see WithAttributes.
+	Attributes map[string]string `json:"attributes" yaml:"attributes"`
+	// ResourceAttributes adds the host, process, build and Kubernetes
+	// attributes; see WithResourceAttributes.
+	ResourceAttributes bool `json:"resource_attributes" yaml:"resource_attributes"`
+	// FieldSeparator and MaxFieldDepth control the flattening of nested
+	// fields; see WithFieldSeparator and WithMaxFieldDepth.
+	FieldSeparator string `json:"field_separator" yaml:"field_separator"`
+	MaxFieldDepth  *int   `json:"max_field_depth" yaml:"max_field_depth"`
+
+	// Redaction configures a Scrubber; see WithScrubber.
+	Redaction RedactionConfig `json:"redaction" yaml:"redaction"`
+	// RateLimit configures WithRateLimit.
+	RateLimit RateLimitConfig `json:"rate_limit" yaml:"rate_limit"`
+	// Deduplication configures WithDeduplication.
+	Deduplication DeduplicationConfig `json:"deduplication" yaml:"deduplication"`
+	// Async configures WithAsync.
+	Async AsyncConfig `json:"async" yaml:"async"`
+
+	// FlushTimeout and PanicFlushTimeout set WithFlushTimeout and
+	// WithPanicFlushTimeout.
+	FlushTimeout      string `json:"flush_timeout" yaml:"flush_timeout"`
+	PanicFlushTimeout string `json:"panic_flush_timeout" yaml:"panic_flush_timeout"`
+
+	// Routes send matching entries to cores of their own; see BuildRouter.
+	// Build ignores them.
+	Routes []RouteConfig `json:"routes" yaml:"routes"`
+}
+
+// RouteConfig describes a Route. All set matchers must match for the route
+// to apply.
+type RouteConfig struct {
+	// LoggerPrefix matches the logger and its children; see
+	// Route.LoggerPrefix.
+	LoggerPrefix string `json:"logger_prefix" yaml:"logger_prefix"`
+	// Level matches entries at or above the level.
+	Level string `json:"level" yaml:"level"`
+	// Field matches entries carrying a field with the value.
+	Field RouteFieldConfig `json:"field" yaml:"field"`
+	// DSN sends the route to a Sentry project of its own. Without it, the
+	// route uses the hub of the default core.
+	DSN string `json:"dsn" yaml:"dsn"`
+	// Config holds the options of the route's core. It does not inherit the
+	// options of the enclosing Config and cannot have routes of its own.
+	Config Config `json:"config" yaml:"config"`
+}
+
+// RouteFieldConfig describes the field matcher of a route; see
+// Route.FieldKey and Route.FieldValue.
+type RouteFieldConfig struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// RedactionConfig describes a Scrubber. It is enabled when any key or value
+// rule is set.
+type RedactionConfig struct {
+	// DenyKeys and AllowKeys are glob patterns; see DenyKeys and AllowKeys.
+	DenyKeys  []string `json:"deny_keys" yaml:"deny_keys"`
+	AllowKeys []string `json:"allow_keys" yaml:"allow_keys"`
+	// Values are regular expressions, or the names of the common patterns:
+	// "bearer_token", "card_number" and "email"; see RedactValues.
+	Values []string `json:"values" yaml:"values"`
+	// Mode is "mask" (the default), "drop" or "hash"; see WithRedaction.
+	Mode string `json:"mode" yaml:"mode"`
+	// HMACKey is the key of the "hash" mode; see WithHMACKey.
+	HMACKey string `json:"hmac_key" yaml:"hmac_key"`
+}
+
+// RateLimitConfig describes WithRateLimit. It is enabled when PerSecond is
+// positive.
+type RateLimitConfig struct {
+	PerSecond float64 `json:"per_second" yaml:"per_second"`
+	Burst     int     `json:"burst" yaml:"burst"`
+	// Key is "message" (the default) or "logger".
+	Key string `json:"key" yaml:"key"`
+}
+
+// DeduplicationConfig describes WithDeduplication. It is enabled when Window
+// is set.
+type DeduplicationConfig struct {
+	Window    string   `json:"window" yaml:"window"`
+	KeyFields []string `json:"key_fields" yaml:"key_fields"`
+}
+
+// AsyncConfig describes WithAsync. It is enabled when Enabled is set. As for
+// WithAsync, pass the options to NewSentryCore, not WithSentryOption, to be
+// able to close the core.
+type AsyncConfig struct {
+	Enabled   bool `json:"enabled" yaml:"enabled"`
+	QueueSize int  `json:"queue_size" yaml:"queue_size"`
+	Workers   int  `json:"workers" yaml:"workers"`
+	// Overflow is "block" (the default), "drop_newest" or "drop_oldest".
+	Overflow string `json:"overflow" yaml:"overflow"`
+}
+
+// ConfigError reports an invalid Config key.
+type ConfigError struct {
+	// Key is the path of the key, such as "rate_limit.per_second", or the
+	// environment variable it was read from.
+	Key string
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("sentryzapcore: config %s: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the reason the key is invalid.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// errUnknownKey reports a key that is not part of Config.
+var errUnknownKey = errors.New("unknown key")
+
+// redactionPatterns are the common patterns RedactionConfig.Values can name.
+var redactionPatterns = map[string]*regexp.Regexp{
+	"bearer_token": BearerTokenPattern,
+	"card_number":  CardNumberPattern,
+	"email":        EmailPattern,
+}
+
+// LoadConfigJSON reads a Config from JSON. Unknown keys are errors.
+func LoadConfigJSON(r io.Reader) (Config, error) {
+	var cfg Config
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("sentryzapcore: config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// LoadConfigYAML reads a Config from YAML. Unknown keys are errors.
+func LoadConfigYAML(r io.Reader) (Config, error) {
+	var cfg Config
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("sentryzapcore: config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// LoadConfigEnv reads a Config from the SENTRY_ZAP_* environment variables.
+// Unknown variables with the prefix are errors.
+func LoadConfigEnv() (Config, error) {
+	var cfg Config
+
+	vars := make(map[string]reflect.Value)
+	collectEnvVars(reflect.ValueOf(&cfg).Elem(), ConfigEnvPrefix, vars)
+
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, ConfigEnvPrefix) {
+			continue
+		}
+
+		field, ok := vars[name]
+		if !ok {
+			return Config{}, &ConfigError{Key: name, Err: errUnknownKey}
+		}
+
+		if err := setEnvValue(field, value); err != nil {
+			return Config{}, &ConfigError{Key: name, Err: err}
+		}
+	}
+
+	return cfg, nil
+}
+
+// collectEnvVars maps the environment variable name of each key of the
+// struct to its field.
+func collectEnvVars(v reflect.Value, prefix string, vars map[string]reflect.Value) {
+	for i := range v.NumField() {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		name = prefix + strings.ToUpper(name)
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct {
+			continue
+		}
+
+		if field.Kind() == reflect.Struct {
+			collectEnvVars(field, name+"_", vars)
+		} else {
+			vars[name] = field
+		}
+	}
+}
+
+// setEnvValue parses an environment variable into the field.
+func setEnvValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(field.Type().Elem())
+		if err := setEnvValue(elem.Elem(), value); err != nil {
+			return err
+		}
+
+		field.Set(elem)
+	case reflect.Slice:
+		field.Set(reflect.ValueOf(splitList(value)))
+	case reflect.Map:
+		m := make(map[string]string)
+
+		for _, pair := range splitList(value) {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("%q is not key=value", pair)
+			}
+
+			m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+
+		field.Set(reflect.ValueOf(m))
+	}
+
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// Build validates the configuration and returns the matching options. The
+// error is a *ConfigError naming the first invalid key.
+func (c Config) Build() ([]SentryCoreOptions, error) {
+	var options []SentryCoreOptions
+
+	levelOption := func(key, value string, option func(zapcore.Level) SentryCoreOptions) error {
+		if value == "" {
+			return nil
+		}
+
+		level, err := zapcore.ParseLevel(value)
+		if err != nil {
+			return &ConfigError{Key: key, Err: err}
+		}
+
+		options = append(options, option(level))
+
+		return nil
+	}
+
+	breadcrumbs := func(level zapcore.Level) SentryCoreOptions { return WithBreadcrumbs(level, c.MaxBreadcrumbs) }
+
+	for _, err := range []error{
+		levelOption("level", c.Level, WithMinLevel),
+		levelOption("event_level", c.EventLevel, WithEventLevel),
+		levelOption("breadcrumb_level", c.BreadcrumbLevel, breadcrumbs),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Loggers != nil {
+		levels, err := LoggerLevelsFromMap(c.Loggers)
+		if err != nil {
+			return nil, &ConfigError{Key: "loggers", Err: err}
+		}
+
+		options = append(options, WithLoggerLevels(levels))
+	}
+
+	if len(c.LevelMapping) > 0 {
+		mapper, err := levelMapperFromMap(c.LevelMapping)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, WithLevelMapper(mapper))
+	}
+
+	if c.StackTrace {
+		options = append(options, WithStackTrace())
+	}
+
+	if len(c.InAppPrefixes) > 0 {
+		options = append(options, WithInAppPrefixes(c.InAppPrefixes...))
+	}
+
+	if len(c.Attributes) > 0 {
+		attrs := make([]attribute.Builder, 0, len(c.Attributes))
+		for k, v := range c.Attributes {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+
+		options = append(options, WithAttributes(attrs...))
+	}
+
+	if c.ResourceAttributes {
+		options = append(options, WithResourceAttributes())
+	}
+
+	if c.FieldSeparator != "" {
+		options = append(options, WithFieldSeparator(c.FieldSeparator))
+	}
+
+	if c.MaxFieldDepth != nil {
+		if *c.MaxFieldDepth < 0 {
+			return nil, &ConfigError{Key: "max_field_depth", Err: errors.New("must not be negative")}
+		}
+
+		options = append(options, WithMaxFieldDepth(*c.MaxFieldDepth))
+	}
+
+	built, err := c.buildFeatures()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(options, built...), nil
+}
+
+// BuildRouter validates the configuration and returns a RouterCore. Its
+// default core uses the options of Build and the hub of ctx, like
+// NewSentryCore; each route gets a SentryCore built from its own Config.
+// Routes with a DSN send to a new client created from clientOptions with that
+// DSN, so clientOptions should enable logs and leave Transport unset unless
+// it can be shared. The error is a *ConfigError naming the first invalid key,
+// such as "routes[1].level".
+func (c Config) BuildRouter(ctx context.Context, clientOptions sentry.ClientOptions) (*RouterCore, error) {
+	options, err := c.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]Route, 0, len(c.Routes))
+
+	for i, rc := range c.Routes {
+		route, err := rc.build(ctx, clientOptions)
+		if err != nil {
+			return nil, prefixConfigError(fmt.Sprintf("routes[%d].", i), err)
+		}
+
+		routes = append(routes, route)
+	}
+
+	return NewRouterCore(NewSentryCore(ctx, options...), routes...), nil
+}
+
+// build returns the Route and its core.
+func (c RouteConfig) build(ctx context.Context, clientOptions sentry.ClientOptions) (Route, error) {
+	route := Route{
+		LoggerPrefix: c.LoggerPrefix,
+		FieldKey:     c.Field.Key,
+		FieldValue:   c.Field.Value,
+	}
+
+	if c.Level != "" {
+		level, err := zapcore.ParseLevel(c.Level)
+		if err != nil {
+			return Route{}, &ConfigError{Key: "level", Err: err}
+		}
+
+		route.Level = level
+	}
+
+	if c.Field.Key == "" && c.Field.Value != "" {
+		return Route{}, &ConfigError{Key: "field.key", Err: errors.New("required by field.value")}
+	}
+
+	if len(c.Config.Routes) > 0 {
+		return Route{}, &ConfigError{Key: "config.routes", Err: errors.New("routes cannot be nested")}
+	}
+
+	options, err := c.Config.Build()
+	if err != nil {
+		return Route{}, prefixConfigError("config.", err)
+	}
+
+	if c.DSN != "" {
+		clientOptions.Dsn = c.DSN
+
+		client, err := sentry.NewClient(clientOptions)
+		if err != nil {
+			return Route{}, &ConfigError{Key: "dsn", Err: err}
+		}
+
+		options = append(options, WithClient(client))
+	}
+
+	route.Core = NewSentryCore(ctx, options...)
+
+	return route, nil
+}
+
+// prefixConfigError prepends the prefix to the key of a *ConfigError.
+func prefixConfigError(prefix string, err error) error {
+	var configErr *ConfigError
+	if errors.As(err, &configErr) {
+		return &ConfigError{Key: prefix + configErr.Key, Err: configErr.Err}
+	}
+
+	return err
+}
+
+// buildFeatures returns the options of the redaction, rate limit,
+// deduplication, async and flush keys.
+func (c Config) buildFeatures() ([]SentryCoreOptions, error) {
+	var options []SentryCoreOptions
+
+	scrubber, err := c.Redaction.build()
+	if err != nil {
+		return nil, err
+	}
+
+	if scrubber != nil {
+		options = append(options, WithScrubber(scrubber))
+	}
+
+	if c.RateLimit.PerSecond < 0 {
+		return nil, &ConfigError{Key: "rate_limit.per_second", Err: errors.New("must not be negative")}
+	}
+
+	if c.RateLimit.PerSecond > 0 {
+		var key RateLimitKeyFunc
+
+		switch c.RateLimit.Key {
+		case "", "message":
+			key = RateLimitByMessage
+		case "logger":
+			key = RateLimitByLogger
+		default:
+			return nil, &ConfigError{Key: "rate_limit.key", Err: fmt.Errorf("unknown key %q, want message or logger", c.RateLimit.Key)}
+		}
+
+		if c.RateLimit.Burst < 1 {
+			return nil, &ConfigError{Key: "rate_limit.burst", Err: errors.New("must be at least 1")}
+		}
+
+		options = append(options, WithRateLimit(c.RateLimit.PerSecond, c.RateLimit.Burst, key))
+	}
+
+	if c.Deduplication.Window != "" {
+		window, err := parseConfigDuration("deduplication.window", c.Deduplication.Window)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, WithDeduplication(window, c.Deduplication.KeyFields...))
+	}
+
+	if c.Async.Enabled {
+		policies := map[string]OverflowPolicy{
+			"":            OverflowBlock,
+			"block":       OverflowBlock,
+			"drop_newest": OverflowDropNewest,
+			"drop_oldest": OverflowDropOldest,
+		}
+
+		policy, ok := policies[c.Async.Overflow]
+		if !ok {
+			return nil, &ConfigError{
+				Key: "async.overflow",
+				Err: fmt.Errorf("unknown policy %q, want block, drop_newest or drop_oldest", c.Async.Overflow),
+			}
+		}
+
+		options = append(options, WithAsync(c.Async.QueueSize, c.Async.Workers, policy))
+	}
+
+	for _, timeout := range []struct {
+		key    string
+		value  string
+		option func(time.Duration) SentryCoreOptions
+	}{
+		{"flush_timeout", c.FlushTimeout, WithFlushTimeout},
+		{"panic_flush_timeout", c.PanicFlushTimeout, WithPanicFlushTimeout},
+	} {
+		if timeout.value == "" {
+			continue
+		}
+
+		d, err := parseConfigDuration(timeout.key, timeout.value)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, timeout.option(d))
+	}
+
+	return options, nil
+}
+
+// build returns the configured Scrubber, or nil when no rule is set.
+func (c RedactionConfig) build() (*Scrubber, error) {
+	if len(c.DenyKeys) == 0 && len(c.AllowKeys) == 0 && len(c.Values) == 0 {
+		return nil, nil
+	}
+
+	options := []ScrubberOption{DenyKeys(c.DenyKeys...), AllowKeys(c.AllowKeys...)}
+
+	for _, value := range c.Values {
+		pattern, ok := redactionPatterns[value]
+		if !ok {
+			var err error
+
+			pattern, err = regexp.Compile(value)
+			if err != nil {
+				return nil, &ConfigError{Key: "redaction.values", Err: err}
+			}
+		}
+
+		options = append(options, RedactValues(pattern))
+	}
+
+	switch c.Mode {
+	case "", "mask":
+	case "drop":
+		options = append(options, WithRedaction(RedactDrop))
+	case "hash":
+		if c.HMACKey == "" {
+			return nil, &ConfigError{Key: "redaction.hmac_key", Err: errors.New("required by the hash mode")}
+		}
+
+		options = append(options, WithRedaction(RedactHash), WithHMACKey([]byte(c.HMACKey)))
+	default:
+		return nil, &ConfigError{Key: "redaction.mode", Err: fmt.Errorf("unknown mode %q, want mask, drop or hash", c.Mode)}
+	}
+
+	return NewScrubber(options...)
+}
+
+// levelMapperFromMap returns a LevelMapper for Config.LevelMapping.
+func levelMapperFromMap(mapping map[string]string) (LevelMapper, error) {
+	severities := map[string]sentry.LogLevel{
+		"trace": sentry.LogLevelTrace,
+		"debug": sentry.LogLevelDebug,
+		"info":  sentry.LogLevelInfo,
+		"warn":  sentry.LogLevelWarn,
+		"error": sentry.LogLevelError,
+		"fatal": sentry.LogLevelFatal,
+	}
+
+	levels := make(map[zapcore.Level]sentry.LogLevel, len(mapping))
+
+	for name, severity := range mapping {
+		key := "level_mapping." + name
+
+		level, err := zapcore.ParseLevel(name)
+		if err != nil {
+			n, convErr := strconv.ParseInt(name, 10, 8)
+			if convErr != nil {
+				return nil, &ConfigError{Key: key, Err: err}
+			}
+
+			level = zapcore.Level(n)
+		}
+
+		logLevel, ok := severities[strings.ToLower(severity)]
+		if !ok {
+			return nil, &ConfigError{Key: key, Err: fmt.Errorf("unknown severity %q", severity)}
+		}
+
+		levels[level] = logLevel
+	}
+
+	return func(level zapcore.Level) sentry.LogLevel {
+		if logLevel, ok := levels[level]; ok {
+			return logLevel
+		}
+
+		return logLevelForLevel(level)
+	}, nil
+}
+
+// parseConfigDuration parses the duration of a key.
+func parseConfigDuration(key, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, &ConfigError{Key: key, Err: err}
+	}
+
+	if d < 0 {
+		return 0, &ConfigError{Key: key, Err: errors.New("must not be negative")}
+	}
+
+	return d, nil
+}
@@ -0,0 +1,306 @@
+package sentryzapcore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLoadConfig(t *testing.T) {
+	depth := 3
+	want := Config{
+		Level:           "warn",
+		EventLevel:      "error",
+		BreadcrumbLevel: "info",
+		MaxBreadcrumbs:  20,
+		Loggers:         map[string]string{"db": "error", "*": "warn"},
+		LevelMapping:    map[string]string{"dpanic": "fatal"},
+		StackTrace:      true,
+		InAppPrefixes:   []string{"github.com/acme"},
+		Attributes:      map[string]string{"team": "checkout"},
+		MaxFieldDepth:   &depth,
+		Redaction:       RedactionConfig{DenyKeys: []string{"password", "*token*"}, Values: []string{"email"}},
+		RateLimit:       RateLimitConfig{PerSecond: 10, Burst: 20, Key: "logger"},
+		Deduplication:   DeduplicationConfig{Window: "5s", KeyFields: []string{"tenant"}},
+		Async:           AsyncConfig{Enabled: true, QueueSize: 4096, Overflow: "drop_oldest"},
+		FlushTimeout:    "3s",
+	}
+
+	fromJSON, err := LoadConfigJSON(strings.NewReader(`{
+		"level": "warn", "event_level": "error", "breadcrumb_level": "info", "max_breadcrumbs": 20,
+		"loggers": {"db": "error", "*": "warn"}, "level_mapping": {"dpanic": "fatal"},
+		"stacktrace": true, "in_app_prefixes": ["github.com/acme"],
+		"attributes": {"team": "checkout"}, "max_field_depth": 3,
+		"redaction": {"deny_keys": ["password", "*token*"], "values": ["email"]},
+		"rate_limit": {"per_second": 10, "burst": 20, "key": "logger"},
+		"deduplication": {"window": "5s", "key_fields": ["tenant"]},
+		"async": {"enabled": true, "queue_size": 4096, "overflow": "drop_oldest"},
+		"flush_timeout": "3s"
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, want, fromJSON)
+
+	fromYAML, err := LoadConfigYAML(strings.NewReader(`
+level: warn
+event_level: error
+breadcrumb_level: info
+max_breadcrumbs: 20
+loggers: {db: error, "*": warn}
+level_mapping: {dpanic: fatal}
+stacktrace: true
+in_app_prefixes: [github.com/acme]
+attributes: {team: checkout}
+max_field_depth: 3
+redaction:
+  deny_keys: [password, "*token*"]
+  values: [email]
+rate_limit: {per_second: 10, burst: 20, key: logger}
+deduplication: {window: 5s, key_fields: [tenant]}
+async: {enabled: true, queue_size: 4096, overflow: drop_oldest}
+flush_timeout: 3s
+`))
+	require.NoError(t, err)
+	require.Equal(t, want, fromYAML)
+
+	for name, value := range map[string]string{
+		"LEVEL":                    "warn",
+		"EVENT_LEVEL":              "error",
+		"BREADCRUMB_LEVEL":         "info",
+		"MAX_BREADCRUMBS":          "20",
+		"LOGGERS":                  "db=error, *=warn",
+		"LEVEL_MAPPING":            "dpanic=fatal",
+		"STACKTRACE":               "true",
+		"IN_APP_PREFIXES":          "github.com/acme",
+		"ATTRIBUTES":               "team=checkout",
+		"MAX_FIELD_DEPTH":          "3",
+		"REDACTION_DENY_KEYS":      "password,*token*",
+		"REDACTION_VALUES":         "email",
+		"RATE_LIMIT_PER_SECOND":    "10",
+		"RATE_LIMIT_BURST":         "20",
+		"RATE_LIMIT_KEY":           "logger",
+		"DEDUPLICATION_WINDOW":     "5s",
+		"DEDUPLICATION_KEY_FIELDS": "tenant",
+		"ASYNC_ENABLED":            "true",
+		"ASYNC_QUEUE_SIZE":         "4096",
+		"ASYNC_OVERFLOW":           "drop_oldest",
+		"FLUSH_TIMEOUT":            "3s",
+	} {
+		t.Setenv(ConfigEnvPrefix+name, value)
+	}
+
+	fromEnv, err := LoadConfigEnv()
+	require.NoError(t, err)
+	require.Equal(t, want, fromEnv)
+
+	options, err := want.Build()
+	require.NoError(t, err)
+	require.NotEmpty(t, options)
+}
+
+func TestLoadConfigUnknownKeys(t *testing.T) {
+	_, err := LoadConfigJSON(strings.NewReader(`{"levle": "warn"}`))
+	require.ErrorContains(t, err, "levle")
+
+	_, err = LoadConfigYAML(strings.NewReader("rate_limit:\n  per_sec: 10\n"))
+	require.ErrorContains(t, err, "per_sec")
+
+	t.Setenv(ConfigEnvPrefix+"RATE_LIMIT_PERSECOND", "10")
+
+	_, err = LoadConfigEnv()
+
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	require.Equal(t, "SENTRY_ZAP_RATE_LIMIT_PERSECOND", configErr.Key)
+}
+
+func TestLoadConfigEnvInvalidValue(t *testing.T) {
+	t.Setenv(ConfigEnvPrefix+"MAX_BREADCRUMBS", "many")
+
+	_, err := LoadConfigEnv()
+
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	require.Equal(t, "SENTRY_ZAP_MAX_BREADCRUMBS", configErr.Key)
+}
+
+func TestConfigBuildErrors(t *testing.T) {
+	negative := -1
+
+	for key, cfg := range map[string]Config{
+		"level":                  {Level: "loud"},
+		"event_level":            {EventLevel: "loud"},
+		"breadcrumb_level":       {BreadcrumbLevel: "loud"},
+		"loggers":                {Loggers: map[string]string{"db": "loud"}},
+		"level_mapping.dpanic":   {LevelMapping: map[string]string{"dpanic": "alert"}},
+		"level_mapping.critical": {LevelMapping: map[string]string{"critical": "fatal"}},
+		"max_field_depth":        {MaxFieldDepth: &negative},
+		"redaction.values":       {Redaction: RedactionConfig{Values: []string{"("}}},
+		"redaction.mode":         {Redaction: RedactionConfig{DenyKeys: []string{"password"}, Mode: "blur"}},
+		"redaction.hmac_key":     {Redaction: RedactionConfig{DenyKeys: []string{"password"}, Mode: "hash"}},
+		"rate_limit.per_second":  {RateLimit: RateLimitConfig{PerSecond: -1}},
+		"rate_limit.burst":       {RateLimit: RateLimitConfig{PerSecond: 1}},
+		"rate_limit.key":         {RateLimit: RateLimitConfig{PerSecond: 1, Burst: 1, Key: "tenant"}},
+		"deduplication.window":   {Deduplication: DeduplicationConfig{Window: "soon"}},
+		"async.overflow":         {Async: AsyncConfig{Enabled: true, Overflow: "spill"}},
+		"flush_timeout":          {FlushTimeout: "-1s"},
+		"panic_flush_timeout":    {PanicFlushTimeout: "later"},
+	} {
+		_, err := cfg.Build()
+
+		var configErr *ConfigError
+		require.ErrorAs(t, err, &configErr, key)
+		require.Equal(t, key, configErr.Key)
+		require.Contains(t, err.Error(), "sentryzapcore: config "+key+":")
+	}
+}
+
+func TestConfigBuildCore(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	cfg, err := LoadConfigYAML(strings.NewReader(`
+level: warn
+loggers: {db: error}
+level_mapping: {dpanic: fatal, "-2": trace}
+attributes: {team: checkout}
+redaction: {deny_keys: [password]}
+`))
+	require.NoError(t, err)
+
+	options, err := cfg.Build()
+	require.NoError(t, err)
+
+	logger := zap.New(NewSentryCore(context.Background(), append(options, WithHub(hub))...))
+
+	warning := gofakeit.Sentence()
+	quietened := gofakeit.Sentence()
+	dpanic := gofakeit.Sentence()
+
+	logger.Warn(warning, zap.String("password", "hunter2"))
+	logger.Named("db").Warn(quietened)
+	logger.DPanic(dpanic)
+	hub.Flush(2 * time.Second)
+
+	logEntry, found := findLog(transport.Events(), warning)
+	require.True(t, found)
+	require.Equal(t, sentry.LogLevelWarn, logEntry.Level)
+	require.Equal(t, "checkout", logEntry.Attributes["team"].String())
+	require.Equal(t, redactedValue, logEntry.Attributes["password"].String())
+
+	_, found = findLog(transport.Events(), quietened)
+	require.False(t, found)
+
+	logEntry, found = findLog(transport.Events(), dpanic)
+	require.True(t, found)
+	require.Equal(t, sentry.LogLevelFatal, logEntry.Level)
+	require.Equal(t, zapcore.DPanicLevel.String(), logEntry.Attributes["zap.level"].String())
+}
+
+func TestConfigLevelMappingDefaults(t *testing.T) {
+	mapper, err := levelMapperFromMap(map[string]string{"warn": "warn"})
+	require.NoError(t, err)
+
+	for level, want := range map[zapcore.Level]sentry.LogLevel{
+		zapcore.Level(-2):      sentry.LogLevelDebug,
+		zapcore.DebugLevel:     sentry.LogLevelDebug,
+		zapcore.InfoLevel:      sentry.LogLevelInfo,
+		zapcore.WarnLevel:      sentry.LogLevelWarn,
+		zapcore.ErrorLevel:     sentry.LogLevelError,
+		zapcore.FatalLevel:     sentry.LogLevelError,
+		zapcore.FatalLevel + 1: sentry.LogLevelError,
+	} {
+		require.Equal(t, want, mapper(level), level.String())
+	}
+
+	core := NewSentryCore(context.Background(), WithLevelMapper(mapper))
+	require.Equal(t, sentry.LevelDebug, core.sentryLevel(zapcore.Level(-2)))
+}
+
+func TestConfigBuildRouter(t *testing.T) {
+	transport := &transportMock{}
+	routeTransport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	cfg, err := LoadConfigYAML(strings.NewReader(`
+level: info
+routes:
+  - logger_prefix: payments
+    dsn: https://public@example.com/2
+    config: {level: warn}
+  - field: {key: team, value: search}
+    level: error
+`))
+	require.NoError(t, err)
+
+	router, err := cfg.BuildRouter(sentry.SetHubOnContext(context.Background(), hub), sentry.ClientOptions{
+		Transport:  routeTransport,
+		EnableLogs: true,
+	})
+	require.NoError(t, err)
+
+	logger := zap.New(router)
+
+	info := gofakeit.UUID()
+	paymentsInfo := gofakeit.UUID()
+	paymentsWarning := gofakeit.UUID()
+	searchInfo := gofakeit.UUID()
+	searchError := gofakeit.UUID()
+
+	logger.Info(info)
+	logger.Named("payments").Info(paymentsInfo)
+	logger.Named("payments.refunds").Warn(paymentsWarning)
+	logger.Info(searchInfo, zap.String("team", "search"))
+	logger.Error(searchError, zap.String("team", "search"))
+	require.NoError(t, logger.Sync())
+
+	for _, message := range []string{info, searchInfo, searchError} {
+		_, found := findLog(transport.Events(), message)
+		require.True(t, found, message)
+	}
+
+	_, found := findLog(routeTransport.Events(), paymentsWarning)
+	require.True(t, found)
+
+	for _, message := range []string{paymentsInfo, paymentsWarning} {
+		_, found = findLog(transport.Events(), message)
+		require.False(t, found, message)
+	}
+
+	_, found = findLog(routeTransport.Events(), paymentsInfo)
+	require.False(t, found)
+}
+
+func TestConfigBuildRouterErrors(t *testing.T) {
+	for key, cfg := range map[string]Config{
+		"level":                          {Level: "loud", Routes: []RouteConfig{{}}},
+		"routes[0].level":                {Routes: []RouteConfig{{Level: "loud"}}},
+		"routes[1].field.key":            {Routes: []RouteConfig{{}, {Field: RouteFieldConfig{Value: "search"}}}},
+		"routes[0].dsn":                  {Routes: []RouteConfig{{DSN: "not a dsn"}}},
+		"routes[0].config.routes":        {Routes: []RouteConfig{{Config: Config{Routes: []RouteConfig{{}}}}}},
+		"routes[0].config.flush_timeout": {Routes: []RouteConfig{{Config: Config{FlushTimeout: "later"}}}},
+	} {
+		_, err := cfg.BuildRouter(context.Background(), sentry.ClientOptions{})
+
+		var configErr *ConfigError
+		require.ErrorAs(t, err, &configErr, key)
+		require.Equal(t, key, configErr.Key)
+		require.Contains(t, err.Error(), "sentryzapcore: config "+key+":")
+	}
+}
+
+func TestLoadConfigEnvRejectsRoutes(t *testing.T) {
+	t.Setenv(ConfigEnvPrefix+"ROUTES", "payments")
+
+	_, err := LoadConfigEnv()
+
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	require.Equal(t, "SENTRY_ZAP_ROUTES", configErr.Key)
+}
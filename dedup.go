@@ -0,0 +1,226 @@
+package sentryzapcore
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// maxDedupWindows caps how long an entry is held, in windows after its first
+// occurrence, when identical entries keep extending its window.
+const maxDedupWindows = 10
+
+// deduplicator merges identical entries logged within a sliding window into
+// one entry. It is shared by the cores derived via With.
+type deduplicator struct {
+	window    time.Duration
+	maxHold   time.Duration // after the first occurrence
+	keyFields []string
+	now       func() time.Time
+	afterFunc func(d time.Duration, f func()) dedupTimer
+
+	mu     sync.Mutex
+	groups map[string]*dedupGroup
+}
+
+// dedupGroup is an entry held for the window, with its occurrences.
+type dedupGroup struct {
+	core        *SentryCore // core of the first occurrence
+	entry       zapcore.Entry
+	encoded     encodedFields
+	occurrences int64
+	firstSeen   time.Time
+	lastSeen    time.Time
+	timer       dedupTimer
+}
+
+// dedupTimer is the part of *time.Timer that releases a group.
+type dedupTimer interface {
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+func newDeduplicator(window time.Duration, keyFields []string) *deduplicator {
+	return &deduplicator{
+		window:    window,
+		maxHold:   maxDedupWindows * window,
+		keyFields: append([]string(nil), keyFields...),
+		now:       time.Now,
+		afterFunc: func(d time.Duration, f func()) dedupTimer { return time.AfterFunc(d, f) },
+		groups:    make(map[string]*dedupGroup),
+	}
+}
+
+// hold records an occurrence of the entry, written by core. The first
+// occurrence is held and emitted through core once a window has elapsed
+// without another one, or maxHold after it; later ones only update its counts
+// and extend the window. It returns false, for the caller to emit the entry,
+// when deduplication is disabled.
+func (d *deduplicator) hold(core *SentryCore, entry zapcore.Entry, encoded encodedFields) bool {
+	if d == nil {
+		return false
+	}
+
+	key := d.key(core, entry, encoded.values)
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if g, ok := d.groups[key]; ok {
+		g.occurrences++
+		g.lastSeen = now
+		g.timer.Reset(min(d.window, g.firstSeen.Add(d.maxHold).Sub(now)))
+
+		return true
+	}
+
+	g := &dedupGroup{
+		core:        core,
+		entry:       entry,
+		encoded:     encoded,
+		occurrences: 1,
+		firstSeen:   now,
+		lastSeen:    now,
+	}
+
+	g.timer = d.afterFunc(d.window, func() {
+		if d.release(key, g) {
+			g.emit()
+		}
+	})
+
+	d.groups[key] = g
+
+	return true
+}
+
+// release forgets the group. It reports false if the group was already
+// released, by flush or by an earlier run of a reset timer.
+func (d *deduplicator) release(key string, g *dedupGroup) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.groups[key] != g {
+		return false
+	}
+
+	delete(d.groups, key)
+
+	return true
+}
+
+// flush emits every held entry without waiting for its window to elapse.
+func (d *deduplicator) flush() {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+
+	groups := make([]*dedupGroup, 0, len(d.groups))
+	for key, g := range d.groups {
+		g.timer.Stop()
+		groups = append(groups, g)
+		delete(d.groups, key)
+	}
+
+	d.mu.Unlock()
+
+	for _, g := range groups {
+		g.emit()
+	}
+}
+
+// key identifies identical entries: same level, message, logger name, values
+// of the key fields, taken from the entry fields or else from the fields
+// added to core via With, and same fields added via With.
+func (d *deduplicator) key(core *SentryCore, entry zapcore.Entry, values map[string]interface{}) string {
+	var b strings.Builder
+
+	b.WriteString(entry.Level.String())
+	b.WriteByte(0)
+	b.WriteString(entry.LoggerName)
+	b.WriteByte(0)
+	b.WriteString(entry.Message)
+
+	for _, field := range d.keyFields {
+		b.WriteByte(0)
+
+		if v, ok := values[field]; ok {
+			fmt.Fprint(&b, v)
+		} else if v, ok := core.dedupWith.value(field); ok {
+			fmt.Fprint(&b, v)
+		}
+	}
+
+	if core.dedupWith != nil {
+		b.WriteByte(0)
+		b.WriteString(core.dedupWith.key)
+	}
+
+	return b.String()
+}
+
+// dedupWith holds the fields added to a core via With. They are part of the
+// deduplication keys, so that the entries of cores derived with different
+// fields are never merged.
+type dedupWith struct {
+	values map[string]interface{} // encoded field values
+	key    string                 // encodes values and the Sentry metadata
+}
+
+// with returns the fields of w, which may be nil, with the given field values
+// added and the resulting Sentry metadata.
+func (w *dedupWith) with(values map[string]interface{}, fields sentryFields) *dedupWith {
+	clone := &dedupWith{values: make(map[string]interface{}, len(values))}
+
+	if w != nil {
+		maps.Copy(clone.values, w.values)
+	}
+
+	maps.Copy(clone.values, values)
+
+	var b strings.Builder
+
+	for _, key := range slices.Sorted(maps.Keys(clone.values)) {
+		fmt.Fprintf(&b, "%s=%v\x00", key, clone.values[key])
+	}
+
+	fmt.Fprintf(&b, "%v\x00%v\x00%v\x00%s\x00%s\x00%v",
+		fields.tags, fields.user, fields.fingerprint, fields.level, fields.transaction, fields.contexts)
+
+	clone.key = b.String()
+
+	return clone
+}
+
+// value returns the value of the field added via With, if any. w may be nil.
+func (w *dedupWith) value(field string) (interface{}, bool) {
+	if w == nil {
+		return nil, false
+	}
+
+	v, ok := w.values[field]
+
+	return v, ok
+}
+
+// emit sends the held entry with its "occurrences", "first_seen" and
+// "last_seen" attributes.
+func (g *dedupGroup) emit() {
+	if g.encoded.values == nil {
+		g.encoded.values = make(map[string]interface{}, 3)
+	}
+
+	g.encoded.values["occurrences"] = g.occurrences
+	g.encoded.values["first_seen"] = g.firstSeen
+	g.encoded.values["last_seen"] = g.lastSeen
+
+	g.core.emit(g.entry, g.encoded)
+}
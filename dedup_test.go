@@ -0,0 +1,227 @@
+package sentryzapcore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDeduplication(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithEventLevel(zapcore.ErrorLevel),
+		WithDeduplication(time.Hour, "tenant"),
+	)
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	core.deduplicator.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	logger := zap.New(core).Named("billing")
+	message := gofakeit.Sentence()
+
+	logger.Error(message, zap.String("tenant", "a"), zap.Int("attempt", 1))
+	logger.Error(message, zap.String("tenant", "a"), zap.Int("attempt", 2))
+	logger.Error(message, zap.String("tenant", "a"), zap.Int("attempt", 3))
+	logger.Error(message, zap.String("tenant", "b"))
+	logger.Named("other").Error(message, zap.String("tenant", "a"))
+	logger.Warn(message, zap.String("tenant", "a"))
+
+	hub.Flush(2 * time.Second)
+	require.Zero(t, countLogs(transport.Events(), message))
+
+	require.NoError(t, core.Sync())
+	hub.Flush(2 * time.Second)
+
+	require.Equal(t, 3, countLogs(transport.Events(), message))
+
+	var merged int
+
+	for _, event := range transport.Events() {
+		for _, log := range event.Logs {
+			if log.Body != message || log.Attributes["tenant"].String() != "a" || log.Attributes["logger"].String() != "billing" {
+				continue
+			}
+
+			merged++
+
+			require.Equal(t, int64(3), log.Attributes["occurrences"].AsInt64())
+			require.Equal(t, int64(1), log.Attributes["attempt"].AsInt64())
+			require.Equal(t, "2026-01-02T03:04:06Z", log.Attributes["first_seen"].String())
+			require.Equal(t, "2026-01-02T03:04:08Z", log.Attributes["last_seen"].String())
+		}
+	}
+
+	require.Equal(t, 1, merged)
+
+	event, found := findEvent(transport.Events(), message)
+	require.True(t, found)
+	require.Contains(t, event.Contexts["fields"], "occurrences")
+}
+
+func TestDeduplicationWithFields(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithDeduplication(time.Hour, "tenant"))
+	logger := zap.New(core)
+	message := gofakeit.Sentence()
+
+	logger.With(zap.String("tenant", "a")).Error(message)
+	logger.With(zap.String("tenant", "b")).Error(message)
+	logger.With(zap.String("tenant", "b")).Error(message)
+	logger.With(zap.String("region", "eu")).Error(message, zap.String("tenant", "b"))
+	logger.With(Tag("region", "eu")).Error(message, zap.String("tenant", "b"))
+
+	require.NoError(t, core.Sync())
+	hub.Flush(2 * time.Second)
+
+	require.Equal(t, 4, countLogs(transport.Events(), message))
+
+	occurrences := make(map[string]int64)
+
+	for _, event := range transport.Events() {
+		for _, log := range event.Logs {
+			if log.Body != message {
+				continue
+			}
+
+			key := log.Attributes["tenant"].String()
+			if region, ok := log.Attributes["region"]; ok {
+				key += "/" + region.String()
+			}
+
+			occurrences[key] += log.Attributes["occurrences"].AsInt64()
+		}
+	}
+
+	require.Equal(t, map[string]int64{"a": 1, "b": 2, "b/eu": 2}, occurrences)
+}
+
+func TestDeduplicationWindow(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithDeduplication(50*time.Millisecond))
+	logger := zap.New(core)
+	message := gofakeit.Sentence()
+
+	// Concurrent writes through cores derived via With are merged.
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			logger.With(zap.String("pool", "workers")).Error(message, zap.Int("worker", i))
+		})
+	}
+
+	wg.Wait()
+
+	require.Eventually(t, func() bool {
+		hub.Flush(time.Second)
+		return countLogs(transport.Events(), message) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	log, found := findLog(transport.Events(), message)
+	require.True(t, found)
+	require.Equal(t, int64(20), log.Attributes["occurrences"].AsInt64())
+
+	// A new window starts once the entry has been sent.
+	logger.Error(message)
+	require.NoError(t, core.Sync())
+	hub.Flush(time.Second)
+	require.Equal(t, 2, countLogs(transport.Events(), message))
+}
+
+func TestDeduplicationSlidingWindow(t *testing.T) {
+	t.Run("extended by each occurrence", func(t *testing.T) {
+		transport := &transportMock{}
+		hub := newTestHub(t, transport)
+
+		core := NewSentryCore(context.Background(), WithHub(hub), WithDeduplication(300*time.Millisecond))
+
+		now := time.Now()
+		core.deduplicator.now = func() time.Time { return now }
+
+		timer := &fakeDedupTimer{}
+		core.deduplicator.afterFunc = func(d time.Duration, f func()) dedupTimer {
+			timer.durations = append(timer.durations, d)
+			timer.fire = f
+
+			return timer
+		}
+
+		logger := zap.New(core)
+		message := gofakeit.Sentence()
+
+		for range 3 {
+			logger.Error(message)
+			now = now.Add(200 * time.Millisecond)
+		}
+
+		// Each occurrence restarts the full window.
+		require.Equal(t, []time.Duration{300 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}, timer.durations)
+
+		hub.Flush(time.Second)
+		require.Zero(t, countLogs(transport.Events(), message))
+
+		timer.fire()
+		hub.Flush(time.Second)
+
+		log, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, int64(3), log.Attributes["occurrences"].AsInt64())
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		transport := &transportMock{}
+		hub := newTestHub(t, transport)
+
+		core := NewSentryCore(context.Background(), WithHub(hub), WithDeduplication(time.Hour))
+		require.Equal(t, maxDedupWindows*time.Hour, core.deduplicator.maxHold)
+
+		now := time.Now()
+		core.deduplicator.now = func() time.Time { return now }
+
+		logger := zap.New(core)
+		message := gofakeit.Sentence()
+
+		logger.Error(message)
+
+		now = now.Add(core.deduplicator.maxHold)
+		logger.Error(message)
+
+		require.Eventually(t, func() bool {
+			hub.Flush(time.Second)
+			return countLogs(transport.Events(), message) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+
+		log, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, int64(2), log.Attributes["occurrences"].AsInt64())
+	})
+}
+
+// fakeDedupTimer records the durations a deduplication timer is started and
+// reset with, and fires only when the test calls fire.
+type fakeDedupTimer struct {
+	durations []time.Duration
+	fire      func()
+}
+
+func (f *fakeDedupTimer) Reset(d time.Duration) bool {
+	f.durations = append(f.durations, d)
+	return true
+}
+
+func (f *fakeDedupTimer) Stop() bool { return true }
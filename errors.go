@@ -0,0 +1,151 @@
+package sentryzapcore
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// maxErrorChainDepth bounds how deep errorChain walks wrapped errors. It
+// matches the default MaxErrorDepth of sentry.ClientOptions.
+const maxErrorChainDepth = 100
+
+// fieldError is an error carried by a zap.Error or zap.NamedError field.
+type fieldError struct {
+	key string
+	err error
+}
+
+// errorChain returns err followed by the errors it wraps, depth first,
+// following Unwrap() error, Unwrap() []error (errors.Join, fmt.Errorf with
+// several %w verbs) and Cause() error (pkg/errors and cockroachdb/errors).
+// This is the order in which Sentry chains exceptions. It stops after
+// maxDepth levels.
+func errorChain(err error, maxDepth int) []error {
+	var chain []error
+
+	var walk func(err error, depth int)
+
+	walk = func(err error, depth int) {
+		if err == nil {
+			return
+		}
+
+		chain = append(chain, err)
+
+		if depth >= maxDepth {
+			return
+		}
+
+		switch v := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, wrapped := range v.Unwrap() {
+				walk(wrapped, depth+1)
+			}
+		case interface{ Unwrap() error }:
+			walk(v.Unwrap(), depth+1)
+		case interface{ Cause() error }:
+			walk(v.Cause(), depth+1)
+		}
+	}
+
+	walk(err, 0)
+
+	return chain
+}
+
+// addErrorValues adds, for every error field, the concrete type of the
+// error ("<key>.type"), the types along its chain ("<key>.chain") and the
+// message of the root cause ("<key>.cause") to the field values, so that
+// structured logs show more than the outermost message. With withStack, the
+// innermost stack trace carried by the chain is added as "<key>.stacktrace".
+func addErrorValues(values map[string]interface{}, errs []fieldError, separator string, withStack bool) {
+	for _, fe := range errs {
+		chain := errorChain(fe.err, maxErrorChainDepth)
+		prefix := fe.key + separator
+
+		values[prefix+"type"] = errorType(fe.err)
+
+		if len(chain) < 2 {
+			continue
+		}
+
+		types := make([]interface{}, len(chain))
+		for i, err := range chain {
+			types[i] = errorType(err)
+		}
+
+		values[prefix+"chain"] = types
+		values[prefix+"cause"] = errorMessage(chain[len(chain)-1])
+
+		if !withStack {
+			continue
+		}
+
+		for i := len(chain) - 1; i >= 0; i-- {
+			if stacktrace := sentry.ExtractStacktrace(chain[i]); stacktrace != nil {
+				values[prefix+"stacktrace"] = formatStacktrace(stacktrace)
+				break
+			}
+		}
+	}
+}
+
+// errorMessage returns err.Error(). Like zapcore does when it encodes an
+// error, it returns "<nil>" when err is a nil pointer whose Error method
+// panics, and lets any other panic through.
+func errorMessage(err error) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			if !isNilPointer(err) {
+				panic(r)
+			}
+
+			msg = "<nil>"
+		}
+	}()
+
+	return err.Error()
+}
+
+// isNilPointer reports whether err holds a nil pointer.
+func isNilPointer(err error) bool {
+	v := reflect.ValueOf(err)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// errorType returns the name of the concrete type of err, such as
+// "*fmt.wrapError", as Sentry reports it for exceptions.
+func errorType(err error) string {
+	return reflect.TypeOf(err).String()
+}
+
+// formatStacktrace formats a sentry.Stacktrace the way zap formats stacks:
+// innermost call first, each function followed by its tab-indented file and
+// line.
+func formatStacktrace(stacktrace *sentry.Stacktrace) string {
+	var b strings.Builder
+
+	for i := len(stacktrace.Frames) - 1; i >= 0; i-- {
+		frame := stacktrace.Frames[i]
+
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+
+		if frame.Module != "" {
+			b.WriteString(frame.Module)
+			b.WriteByte('.')
+		}
+
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.AbsPath)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Lineno))
+	}
+
+	return b.String()
+}
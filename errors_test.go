@@ -0,0 +1,212 @@
+package sentryzapcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adlandh/sentry-zapcore/v2/internal/stacktest"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// stackFrame mirrors the program counter frames of pkg/errors and
+// cockroachdb/errors.
+type stackFrame uintptr
+
+// stackError is a pkg/errors-style error: it records the stack where it was
+// created and exposes its cause through Cause.
+type stackError struct {
+	msg   string
+	cause error
+	stack []stackFrame
+}
+
+func newStackError(msg string, cause error) *stackError {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+
+	stack := make([]stackFrame, n)
+	for i := range stack {
+		stack[i] = stackFrame(pcs[i])
+	}
+
+	return &stackError{msg: msg, cause: cause, stack: stack}
+}
+
+func (e *stackError) Error() string {
+	if e.cause == nil {
+		return e.msg
+	}
+
+	return e.msg + ": " + e.cause.Error()
+}
+
+func (e *stackError) Cause() error { return e.cause }
+
+func (e *stackError) StackTrace() []stackFrame { return e.stack }
+
+func TestErrorChain(t *testing.T) {
+	root := errors.New("root")
+	stacked := newStackError("stacked", root)
+	wrapped := fmt.Errorf("outer: %w", stacked)
+
+	require.Equal(t, []error{wrapped, stacked, root}, errorChain(wrapped, maxErrorChainDepth))
+	require.Equal(t, []error{wrapped, stacked}, errorChain(wrapped, 1))
+
+	other := errors.New("other")
+	joined := errors.Join(wrapped, other)
+	require.Equal(t, []error{joined, wrapped, stacked, root, other}, errorChain(joined, maxErrorChainDepth))
+
+	require.Nil(t, errorChain(nil, maxErrorChainDepth))
+}
+
+func TestErrorChainEvent(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	logger := zap.New(NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithEventLevel(zapcore.ErrorLevel),
+	))
+
+	t.Run("wrapped error", func(t *testing.T) {
+		root := errors.New("connection refused")
+		err := fmt.Errorf("handle request: %w", fmt.Errorf("load user: %w", newStackError("query", root)))
+
+		message := gofakeit.Sentence()
+		stacktest.Call(func() { logger.Error(message, zap.Error(err)) })
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Len(t, event.Exception, 4)
+
+		// Root cause first, outermost error last.
+		require.Equal(t, "connection refused", event.Exception[0].Value)
+		require.Equal(t, "*errors.errorString", event.Exception[0].Type)
+		require.Equal(t, "*sentryzapcore.stackError", event.Exception[1].Type)
+		require.NotNil(t, event.Exception[1].Stacktrace)
+		require.NotEmpty(t, event.Exception[1].Stacktrace.Frames)
+		require.Equal(t, "*fmt.wrapError", event.Exception[3].Type)
+		require.Equal(t, err.Error(), event.Exception[3].Value)
+
+		requireStackTop(t, event.Exception[3].Stacktrace)
+	})
+
+	t.Run("joined errors", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		logger.Error(message,
+			zap.Error(fmt.Errorf("first: %w", errors.New("a"))),
+			zap.NamedError("second", errors.New("b")),
+		)
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+
+		values := make([]string, len(event.Exception))
+		for i, exception := range event.Exception {
+			values[i] = exception.Value
+		}
+
+		require.Contains(t, values, "a")
+		require.Contains(t, values, "b")
+		require.Contains(t, values, "first: a")
+	})
+}
+
+func TestErrorChainLog(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	logger := zap.New(NewSentryCore(context.Background(), WithHub(hub), WithStackTrace()))
+
+	t.Run("wrapped error", func(t *testing.T) {
+		err := fmt.Errorf("handle request: %w", newStackError("query", errors.New("connection refused")))
+
+		message := gofakeit.Sentence()
+		logger.Error(message, zap.Error(err))
+
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, err.Error(), logEntry.Attributes["error"].String())
+		require.Equal(t, "*fmt.wrapError", logEntry.Attributes["error.type"].String())
+		require.Equal(t, "connection refused", logEntry.Attributes["error.cause"].String())
+		require.Equal(t,
+			[]string{"*fmt.wrapError", "*sentryzapcore.stackError", "*errors.errorString"},
+			logEntry.Attributes["error.chain"].AsStringSlice(),
+		)
+
+		stack := logEntry.Attributes["error.stacktrace"].String()
+		require.True(t, strings.HasPrefix(stack, "github.com/adlandh/sentry-zapcore/v2.TestErrorChainLog.func1\n\t"), stack)
+		require.Contains(t, stack, "errors_test.go:")
+	})
+
+	t.Run("plain error", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		logger.Error(message, zap.NamedError("failure", errors.New("boom")))
+
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, "boom", logEntry.Attributes["failure"].String())
+		require.Equal(t, "*errors.errorString", logEntry.Attributes["failure.type"].String())
+		require.NotContains(t, logEntry.Attributes, "failure.cause")
+		require.NotContains(t, logEntry.Attributes, "failure.stacktrace")
+	})
+}
+
+// nilPointerError is an error whose Error method panics on a nil receiver.
+type nilPointerError struct{ msg string }
+
+func (e *nilPointerError) Error() string { return e.msg }
+
+func TestErrorChainNilPointer(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	err := fmt.Errorf("wrap: %w", (*nilPointerError)(nil))
+
+	t.Run("log", func(t *testing.T) {
+		logger := zap.New(NewSentryCore(context.Background(), WithHub(hub)))
+
+		message := gofakeit.Sentence()
+		require.NotPanics(t, func() { logger.Error(message, zap.Error(err)) })
+
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, "<nil>", logEntry.Attributes["error.cause"].String())
+		require.Equal(t,
+			[]string{"*fmt.wrapError", "*sentryzapcore.nilPointerError"},
+			logEntry.Attributes["error.chain"].AsStringSlice(),
+		)
+	})
+
+	t.Run("event", func(t *testing.T) {
+		logger := zap.New(NewSentryCore(context.Background(),
+			WithHub(hub),
+			WithEventLevel(zapcore.ErrorLevel),
+		))
+
+		message := gofakeit.Sentence()
+		require.NotPanics(t, func() { logger.Error(message, zap.Error(err)) })
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Len(t, event.Exception, 2)
+		require.Equal(t, "<nil>", event.Exception[0].Value)
+		require.Equal(t, "*sentryzapcore.nilPointerError", event.Exception[0].Type)
+		require.Equal(t, err.Error(), event.Exception[1].Value)
+		require.NotNil(t, event.Exception[1].Stacktrace)
+	})
+}
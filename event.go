@@ -0,0 +1,258 @@
+package sentryzapcore
+
+import (
+	"errors"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zapcore"
+)
+
+// eventEnabled reports whether entries at the given level are captured as
+// Sentry events.
+func (s *SentryCore) eventEnabled(level zapcore.Level) bool {
+	return s.eventLevel != nil && s.eventLevel.Enabled(level)
+}
+
+// captureEvent builds a sentry.Event from the entry and captures it with the
+// client of the hub resolved for the entry, so that it opens (or updates) a
+// Sentry Issue. A span carried by the entry context, and the tags, user,
+// fingerprint, level and contexts set with the field constructors, are set
+// on a clone of the hub scope, linking the event to its trace.
+func (s *SentryCore) captureEvent(entry zapcore.Entry, encoded encodedFields) {
+	ctx := s.ctx
+	if encoded.ctx != nil {
+		ctx = encoded.ctx
+	}
+
+	hub := s.hubFor(ctx)
+
+	client := hub.Client()
+	if client == nil {
+		return
+	}
+
+	scope := hub.Scope()
+
+	span := sentry.SpanFromContext(ctx)
+	if span != nil || !encoded.fields.empty() {
+		scope = scope.Clone()
+		encoded.fields.applyToScope(scope)
+
+		if span != nil {
+			scope.SetSpan(span)
+		}
+	}
+
+	event := s.eventFromEntry(entry, encoded, client.Options().MaxErrorDepth)
+	client.CaptureEvent(event, &sentry.EventHint{Context: ctx}, scope)
+}
+
+// eventFromEntry converts a zap entry and its encoded fields into a
+// sentry.Event. Core attributes (from With) and entry fields are reported
+// under the "fields" context; errors become the event exception.
+func (s *SentryCore) eventFromEntry(entry zapcore.Entry, encoded encodedFields, maxErrorDepth int) *sentry.Event {
+	event := sentry.NewEvent()
+	event.Level = s.sentryLevel(entry.Level)
+	event.Tags = map[string]string{"zap.level": entry.Level.String()}
+	event.Message = entry.Message
+	event.Logger = entry.LoggerName
+	event.Transaction = encoded.fields.transaction
+
+	if !entry.Time.IsZero() {
+		event.Timestamp = entry.Time
+	}
+
+	fields := make(sentry.Context, len(s.attributes)+len(encoded.values))
+	for _, attr := range s.attributes {
+		fields[attr.Key] = attr.Value.AsInterface()
+	}
+
+	for k, v := range encoded.values {
+		fields[k] = v
+	}
+
+	if len(fields) > 0 {
+		event.Contexts["fields"] = fields
+	}
+
+	if entry.Caller.Defined {
+		event.Contexts["caller"] = sentry.Context{
+			"file":     entry.Caller.File,
+			"line":     entry.Caller.Line,
+			"function": entry.Caller.Function,
+		}
+	}
+
+	var err error
+
+	switch len(encoded.errs) {
+	case 0:
+	case 1:
+		err = encoded.errs[0].err
+	default:
+		errs := make([]error, len(encoded.errs))
+		for i, fe := range encoded.errs {
+			errs[i] = fe.err
+		}
+
+		err = errors.Join(errs...)
+	}
+
+	// SetException chains every error wrapped by err, with its concrete
+	// type and the stack trace it carries, root cause first.
+	setException(event, err, maxErrorDepth)
+
+	for i := range event.Exception {
+		event.Exception[i].Value = s.scrubber.scrubString(event.Exception[i].Value)
+	}
+
+	s.setEventStacktrace(event, err, entry.Stack)
+
+	return event
+}
+
+// setException sets the exceptions of event with event.SetException. Should
+// an error of the chain be a nil pointer whose Error method panics, which
+// SetException does not guard against, the exceptions are built from
+// errorChain instead, root cause first, with "<nil>" as the message of such
+// errors.
+func setException(event *sentry.Event, err error, maxErrorDepth int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if !hasNilPointer(err) {
+				panic(r)
+			}
+
+			event.Exception = exceptionsFromChain(err, maxErrorDepth)
+		}
+	}()
+
+	event.SetException(err, maxErrorDepth)
+}
+
+// hasNilPointer reports whether the chain of err holds a nil pointer.
+func hasNilPointer(err error) bool {
+	for _, e := range errorChain(err, maxErrorChainDepth) {
+		if isNilPointer(e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exceptionsFromChain returns the exceptions of the chain of err, root cause
+// first, without calling the methods of the nil pointers it holds. The
+// outermost exception gets the current stack trace when it has none, as
+// SetException does. A negative maxDepth falls back to maxErrorChainDepth.
+func exceptionsFromChain(err error, maxDepth int) []sentry.Exception {
+	if maxDepth < 0 {
+		maxDepth = maxErrorChainDepth
+	}
+
+	chain := errorChain(err, maxDepth)
+	exceptions := make([]sentry.Exception, 0, len(chain))
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		exception := sentry.Exception{
+			Type:  errorType(chain[i]),
+			Value: errorMessage(chain[i]),
+		}
+
+		if !isNilPointer(chain[i]) {
+			exception.Stacktrace = sentry.ExtractStacktrace(chain[i])
+		}
+
+		exceptions = append(exceptions, exception)
+	}
+
+	if outermost := &exceptions[len(exceptions)-1]; outermost.Stacktrace == nil {
+		outermost.Stacktrace = sentry.NewStacktrace()
+	}
+
+	return exceptions
+}
+
+// setEventStacktrace attaches the entry stack, parsed into frames when
+// WithStackTrace is set, to the event: to the outermost exception when its
+// error carries no stack trace of its own, or as the current thread when the
+// event has no exception. Otherwise, the stack Sentry records for such an
+// exception is trimmed of the logging frames.
+func (s *SentryCore) setEventStacktrace(event *sentry.Event, err error, stack string) {
+	var stacktrace *sentry.Stacktrace
+	if s.stackTrace {
+		stacktrace = stacktraceFromString(stack, s.inAppPrefixes)
+	}
+
+	if len(event.Exception) == 0 {
+		if stacktrace != nil {
+			event.Threads = []sentry.Thread{{Stacktrace: stacktrace, Current: true}}
+		}
+
+		return
+	}
+
+	outermost := &event.Exception[len(event.Exception)-1]
+
+	if sentry.ExtractStacktrace(err) == nil {
+		switch {
+		case stacktrace != nil:
+			outermost.Stacktrace = stacktrace
+		case outermost.Stacktrace != nil:
+			outermost.Stacktrace.Frames = trimFramesTop(outermost.Stacktrace.Frames)
+		}
+	}
+
+	if len(s.inAppPrefixes) == 0 {
+		return
+	}
+
+	for i := range event.Exception {
+		if event.Exception[i].Stacktrace == nil {
+			continue
+		}
+
+		frames := event.Exception[i].Stacktrace.Frames
+		for j := range frames {
+			frames[j].InApp = hasAnyPrefix(frames[j].Module, s.inAppPrefixes)
+		}
+	}
+}
+
+// sentryLevel returns the sentry.Level of events and breadcrumbs for the
+// given zap log level, using the level mapper if one is set.
+func (s *SentryCore) sentryLevel(level zapcore.Level) sentry.Level {
+	if s.levelMapper == nil {
+		return eventLevelForLevel(level)
+	}
+
+	switch s.levelMapper(level) {
+	case sentry.LogLevelTrace, sentry.LogLevelDebug:
+		return sentry.LevelDebug
+	case sentry.LogLevelInfo:
+		return sentry.LevelInfo
+	case sentry.LogLevelWarn:
+		return sentry.LevelWarning
+	case sentry.LogLevelFatal:
+		return sentry.LevelFatal
+	default:
+		return sentry.LevelError
+	}
+}
+
+// eventLevelForLevel returns the sentry.Level for the given zap log level.
+// DPanic, Panic, and Fatal map to Fatal.
+func eventLevelForLevel(level zapcore.Level) sentry.Level {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return sentry.LevelDebug
+	case level == zapcore.InfoLevel:
+		return sentry.LevelInfo
+	case level == zapcore.WarnLevel:
+		return sentry.LevelWarning
+	case level == zapcore.ErrorLevel:
+		return sentry.LevelError
+	default:
+		return sentry.LevelFatal
+	}
+}
@@ -0,0 +1,122 @@
+package sentryzapcore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newTestHub returns a hub bound to a fresh client that sends through the
+// given transport, so tests do not depend on the global hub.
+func newTestHub(t *testing.T, transport sentry.Transport) *sentry.Hub {
+	t.Helper()
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Transport:   transport,
+		Environment: "test",
+		EnableLogs:  true,
+	})
+	require.NoError(t, err)
+
+	return sentry.NewHub(client, sentry.NewScope())
+}
+
+func findEvent(events []*sentry.Event, message string) (*sentry.Event, bool) {
+	for _, event := range events {
+		if event.Message == message {
+			return event, true
+		}
+	}
+
+	return nil, false
+}
+
+func TestEventCapture(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	core := NewSentryCore(ctx, WithEventLevel(zapcore.ErrorLevel))
+	logger := zap.New(core).Named("events").With(zap.String("component", "auth"))
+
+	t.Run("error entry becomes an event", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		logger.Error(message, zap.Int("retry", 3), zap.Error(errors.New("boom")))
+		hub.Flush(2 * time.Second)
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, sentry.LevelError, event.Level)
+		require.Equal(t, "events", event.Logger)
+		require.Equal(t, "auth", event.Contexts["fields"]["component"])
+		require.Equal(t, int64(3), event.Contexts["fields"]["retry"])
+		require.Len(t, event.Exception, 1)
+		require.Equal(t, "boom", event.Exception[0].Value)
+
+		_, found = findLog(transport.Events(), message)
+		require.True(t, found)
+	})
+
+	t.Run("warn entry is not captured", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		logger.Warn(message)
+		hub.Flush(2 * time.Second)
+
+		_, found := findEvent(transport.Events(), message)
+		require.False(t, found)
+	})
+
+	t.Run("event level below log level", func(t *testing.T) {
+		warnCore := NewSentryCore(ctx, WithEventLevel(zapcore.WarnLevel))
+		warnLogger := zap.New(warnCore)
+
+		message := gofakeit.Sentence()
+		warnLogger.Warn(message)
+		hub.Flush(2 * time.Second)
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, sentry.LevelWarning, event.Level)
+
+		_, found = findLog(transport.Events(), message)
+		require.False(t, found)
+	})
+
+	t.Run("span context links the trace", func(t *testing.T) {
+		span := sentry.StartSpan(ctx, gofakeit.Word())
+		defer span.Finish()
+
+		message := gofakeit.Sentence()
+		logger.Error(message, zap.Field{Key: "ctx", Type: zapcore.SkipType, Interface: span.Context()})
+		hub.Flush(2 * time.Second)
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, span.TraceID, event.Contexts["trace"]["trace_id"])
+		require.Equal(t, span.SpanID, event.Contexts["trace"]["span_id"])
+	})
+}
+
+func TestEventLevelForLevel(t *testing.T) {
+	cases := map[zapcore.Level]sentry.Level{
+		zapcore.DebugLevel - 1: sentry.LevelDebug,
+		zapcore.DebugLevel:     sentry.LevelDebug,
+		zapcore.InfoLevel:      sentry.LevelInfo,
+		zapcore.WarnLevel:      sentry.LevelWarning,
+		zapcore.ErrorLevel:     sentry.LevelError,
+		zapcore.DPanicLevel:    sentry.LevelFatal,
+		zapcore.PanicLevel:     sentry.LevelFatal,
+		zapcore.FatalLevel:     sentry.LevelFatal,
+	}
+
+	for level, want := range cases {
+		require.Equal(t, want, eventLevelForLevel(level), level.String())
+	}
+}
@@ -0,0 +1,258 @@
+package sentryzapcore
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/getsentry/sentry-go/attribute"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// The field constructors below return zap.SkipType fields: other cores ignore
+// them, while SentryCore routes their values to the matching part of the
+// Sentry payload instead of reporting them as attributes. They can be passed
+// to log calls or to With, where entry fields take precedence.
+
+// Context returns a field carrying ctx. The hub and span of ctx are used for
+// the entry, linking it to the current trace.
+func Context(ctx context.Context) zap.Field {
+	return zap.Field{Key: "context", Type: zapcore.SkipType, Interface: ctx}
+}
+
+// Tag returns a field that sets a Sentry tag on captured events. Structured
+// logs get it as an attribute.
+func Tag(key, value string) zap.Field {
+	return zap.Field{Key: key, Type: zapcore.SkipType, Interface: tagField(value)}
+}
+
+// User returns a field that sets the Sentry user of captured events and the
+// "user.id", "user.email" and "user.name" attributes of structured logs.
+func User(id, email, username string) zap.Field {
+	return zap.Field{
+		Key:       "user",
+		Type:      zapcore.SkipType,
+		Interface: sentry.User{ID: id, Email: email, Username: username},
+	}
+}
+
+// Fingerprint returns a field that sets the fingerprint Sentry groups
+// captured events by.
+func Fingerprint(fingerprint ...string) zap.Field {
+	return zap.Field{
+		Key:       "fingerprint",
+		Type:      zapcore.SkipType,
+		Interface: fingerprintField(append([]string(nil), fingerprint...)),
+	}
+}
+
+// Level returns a field that overrides the Sentry level of the entry, in
+// place of the one mapped from the zap level. It applies to captured events,
+// breadcrumbs and the severity of structured logs.
+func Level(level sentry.Level) zap.Field {
+	return zap.Field{Key: "level", Type: zapcore.SkipType, Interface: level}
+}
+
+// Transaction returns a field that sets the transaction name of captured
+// events.
+func Transaction(name string) zap.Field {
+	return zap.Field{Key: "transaction", Type: zapcore.SkipType, Interface: transactionField(name)}
+}
+
+// Contexts returns a field that adds a named context, such as "order", to
+// captured events.
+func Contexts(name string, values map[string]interface{}) zap.Field {
+	return zap.Field{Key: name, Type: zapcore.SkipType, Interface: sentry.Context(values)}
+}
+
+type (
+	tagField         string
+	fingerprintField []string
+	transactionField string
+)
+
+// sentryFields holds the values of the field constructors above.
+type sentryFields struct {
+	tags        map[string]string
+	user        *sentry.User
+	fingerprint []string
+	level       sentry.Level
+	transaction string
+	contexts    map[string]sentry.Context
+}
+
+// add records the value of a SkipType field built by one of the field
+// constructors other than Context. Other fields are ignored.
+func (m *sentryFields) add(f zapcore.Field) {
+	switch v := f.Interface.(type) {
+	case tagField:
+		if m.tags == nil {
+			m.tags = make(map[string]string)
+		}
+
+		m.tags[f.Key] = string(v)
+	case sentry.User:
+		m.user = &v
+	case fingerprintField:
+		m.fingerprint = v
+	case sentry.Level:
+		m.level = v
+	case transactionField:
+		m.transaction = string(v)
+	case sentry.Context:
+		if m.contexts == nil {
+			m.contexts = make(map[string]sentry.Context)
+		}
+
+		m.contexts[f.Key] = v
+	}
+}
+
+// merge returns m overridden by the values set in other.
+func (m sentryFields) merge(other sentryFields) sentryFields {
+	merged := m
+
+	merged.tags = mergeMaps(m.tags, other.tags)
+	merged.contexts = mergeMaps(m.contexts, other.contexts)
+
+	if other.user != nil {
+		merged.user = other.user
+	}
+
+	if other.fingerprint != nil {
+		merged.fingerprint = other.fingerprint
+	}
+
+	if other.level != "" {
+		merged.level = other.level
+	}
+
+	if other.transaction != "" {
+		merged.transaction = other.transaction
+	}
+
+	return merged
+}
+
+// empty reports whether no value is set.
+func (m sentryFields) empty() bool {
+	return len(m.tags) == 0 && m.user == nil && m.fingerprint == nil &&
+		m.level == "" && m.transaction == "" && len(m.contexts) == 0
+}
+
+// applyToScope sets the values, but the transaction, on a scope, so that
+// they take precedence over the hub scope when an event is captured with it.
+// The scope has no transaction name; it is set on the event instead.
+func (m sentryFields) applyToScope(scope *sentry.Scope) {
+	scope.SetTags(m.tags)
+
+	for name, values := range m.contexts {
+		scope.SetContext(name, values)
+	}
+
+	if m.user != nil {
+		scope.SetUser(*m.user)
+	}
+
+	if m.fingerprint != nil {
+		scope.SetFingerprint(m.fingerprint)
+	}
+
+	if m.level != "" {
+		scope.SetLevel(m.level)
+	}
+}
+
+// scrub returns the values redacted by the scrubber like the attributes of
+// the entry: tags by their key, the user by the keys of logAttributes and
+// contexts by their name and nested keys. The maps of m are not modified.
+func (m sentryFields) scrub(scrubber *Scrubber, separator string) sentryFields {
+	if scrubber == nil {
+		return m
+	}
+
+	scrubString := func(key, value string) string {
+		if value == "" {
+			return ""
+		}
+
+		scrubbed, keep := scrubber.scrubAttribute(key, value, separator)
+		if !keep {
+			return ""
+		}
+
+		return scrubbed.(string)
+	}
+
+	if m.tags != nil {
+		tags := make(map[string]string, len(m.tags))
+		for k, v := range m.tags {
+			if v = scrubString(k, v); v != "" {
+				tags[k] = v
+			}
+		}
+
+		m.tags = tags
+	}
+
+	if m.user != nil {
+		user := *m.user
+		user.ID = scrubString("user.id", user.ID)
+		user.Email = scrubString("user.email", user.Email)
+		user.Username = scrubString("user.name", user.Username)
+		m.user = &user
+	}
+
+	if m.contexts != nil {
+		contexts := make(map[string]sentry.Context, len(m.contexts))
+		for name, values := range m.contexts {
+			contexts[name] = scrubber.scrubMap(values, name, separator)
+		}
+
+		m.contexts = contexts
+	}
+
+	return m
+}
+
+// logAttributes returns the tags and user as structured log attributes.
+func (m sentryFields) logAttributes() []attribute.Builder {
+	attrs := make([]attribute.Builder, 0, len(m.tags)+3)
+
+	for k, v := range m.tags {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	if m.user != nil {
+		if m.user.ID != "" {
+			attrs = append(attrs, attribute.String("user.id", m.user.ID))
+		}
+
+		if m.user.Email != "" {
+			attrs = append(attrs, attribute.String("user.email", m.user.Email))
+		}
+
+		if m.user.Username != "" {
+			attrs = append(attrs, attribute.String("user.name", m.user.Username))
+		}
+	}
+
+	return attrs
+}
+
+func mergeMaps[V any](base, override map[string]V) map[string]V {
+	if len(override) == 0 {
+		return base
+	}
+
+	merged := make(map[string]V, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+
+	for k, v := range override {
+		merged[k] = v
+	}
+
+	return merged
+}
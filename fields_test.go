@@ -0,0 +1,110 @@
+package sentryzapcore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFieldConstructors(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	hub.Scope().SetTag("region", "eu")
+	hub.Scope().SetUser(sentry.User{ID: "scope-user"})
+
+	core := NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithEventLevel(zapcore.ErrorLevel),
+		WithBreadcrumbs(zapcore.DebugLevel, 10),
+	)
+	logger := zap.New(core).With(Tag("service", "checkout"), Transaction("POST /orders"))
+
+	t.Run("event", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		logger.Error(message,
+			Tag("region", "us"),
+			User("42", "jane@example.com", "jane"),
+			Fingerprint("orders", "{{ default }}"),
+			Level(sentry.LevelWarning),
+			Contexts("order", map[string]interface{}{"id": "o-1", "total": 99}),
+			zap.String("step", "payment"),
+		)
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, "checkout", event.Tags["service"])
+		require.Equal(t, "us", event.Tags["region"])
+		require.Equal(t, sentry.User{ID: "42", Email: "jane@example.com", Username: "jane"}, event.User)
+		require.Equal(t, []string{"orders", "{{ default }}"}, event.Fingerprint)
+		require.Equal(t, sentry.LevelWarning, event.Level)
+		require.Equal(t, "POST /orders", event.Transaction)
+		require.Equal(t, sentry.Context{"id": "o-1", "total": 99}, event.Contexts["order"])
+
+		fields := event.Contexts["fields"]
+		require.Equal(t, "payment", fields["step"])
+		require.NotContains(t, fields, "region")
+		require.NotContains(t, fields, "user")
+		require.NotContains(t, fields, "order")
+	})
+
+	t.Run("hub scope is unchanged", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		zap.New(core).Error(message)
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, "eu", event.Tags["region"])
+		require.Equal(t, "scope-user", event.User.ID)
+		require.Empty(t, event.Fingerprint)
+	})
+
+	t.Run("log", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		logger.Error(message, User("42", "", "jane"), Level(sentry.LevelFatal), Contexts("order", map[string]interface{}{"id": "o-1"}))
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, sentry.LogLevelFatal, logEntry.Level)
+		require.Equal(t, "checkout", logEntry.Attributes["service"].String())
+		require.Equal(t, "42", logEntry.Attributes["user.id"].String())
+		require.Equal(t, "jane", logEntry.Attributes["user.name"].String())
+		require.NotContains(t, logEntry.Attributes, "order")
+		require.NotContains(t, logEntry.Attributes, "transaction")
+	})
+
+	t.Run("breadcrumb", func(t *testing.T) {
+		breadcrumb := gofakeit.Sentence()
+		logger.Debug(breadcrumb, Level(sentry.LevelInfo))
+
+		message := gofakeit.Sentence()
+		logger.Error(message)
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.NotEmpty(t, event.Breadcrumbs)
+
+		last := event.Breadcrumbs[len(event.Breadcrumbs)-1]
+		require.Equal(t, breadcrumb, last.Message)
+		require.Equal(t, sentry.LevelInfo, last.Level)
+	})
+}
+
+func TestContextField(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	logger := zap.New(NewSentryCore(context.Background(), WithEventLevel(zapcore.ErrorLevel)))
+
+	message := gofakeit.Sentence()
+	logger.Error(message, Context(sentry.SetHubOnContext(context.Background(), hub)))
+
+	_, found := findEvent(transport.Events(), message)
+	require.True(t, found)
+}
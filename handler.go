@@ -0,0 +1,177 @@
+package sentryzapcore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// errLevelNotAtomic is returned by the level handler when the level of the
+	// core was not set with WithAtomicLevel.
+	errLevelNotAtomic = errors.New("level can only be changed when set with WithAtomicLevel")
+	// errLoggersNotSet is returned by the level handler when the core has no
+	// table set with WithLoggerLevels.
+	errLoggersNotSet = errors.New("logger levels can only be changed when set with WithLoggerLevels")
+	// errNoLevelChange is returned by the level handler for a PUT request
+	// that changes nothing.
+	errNoLevelChange = errors.New("must specify level or loggers")
+)
+
+// levelHandler serves the levels of a core. See SentryCore.LevelHandler.
+type levelHandler struct {
+	core *SentryCore
+}
+
+// levelPayload describes the levels of a core.
+type levelPayload struct {
+	Level       string            `json:"level"`
+	Loggers     map[string]string `json:"loggers,omitempty"`
+	Events      string            `json:"events,omitempty"`
+	Breadcrumbs string            `json:"breadcrumbs,omitempty"`
+}
+
+// levelRequest is the body of a PUT request.
+type levelRequest struct {
+	Level   *zapcore.Level     `json:"level"`
+	Loggers *map[string]string `json:"loggers"`
+}
+
+// LevelHandler returns a JSON endpoint that reports and changes the levels
+// of the core at runtime, in the manner of zap.AtomicLevel.ServeHTTP.
+//
+// A GET request reports what the core currently sends to Sentry: the
+// minimum level of structured logs, the per-logger rules and the minimum
+// levels of captured events and breadcrumbs, when enabled:
+//
+//	{"level":"error","loggers":{"db":"warn","*":"error"},"events":"error"}
+//
+// A PUT request changes the level set with WithAtomicLevel, the rules set
+// with WithLoggerLevels, or both, and reports the result. With the
+// application/x-www-form-urlencoded content type, the level and the rules,
+// in the format read by ParseLoggerLevels, are read from the form:
+//
+//	curl -X PUT localhost:8080/sentry/level -d level=warn -d loggers=db=error,http=info
+//
+// Any other content type is read as JSON, replacing the whole rule table:
+//
+//	{"level":"warn","loggers":{"db":"error","http":"info"}}
+func (s *SentryCore) LevelHandler() http.Handler {
+	return levelHandler{core: s}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+
+	switch r.Method {
+	case http.MethodGet:
+		_ = enc.Encode(h.payload())
+	case http.MethodPut:
+		if err := h.update(r); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = enc.Encode(map[string]string{"error": err.Error()})
+
+			return
+		}
+
+		_ = enc.Encode(h.payload())
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_ = enc.Encode(map[string]string{"error": "Only GET and PUT are supported."})
+	}
+}
+
+// payload describes the current levels of the core.
+func (h levelHandler) payload() levelPayload {
+	payload := levelPayload{Level: zapcore.LevelOf(h.core.LevelEnabler).String()}
+
+	if h.core.loggerLevels != nil {
+		payload.Loggers = h.core.loggerLevels.Map()
+	}
+
+	if h.core.eventLevel != nil {
+		payload.Events = zapcore.LevelOf(h.core.eventLevel).String()
+	}
+
+	if h.core.breadcrumbLevel != nil {
+		payload.Breadcrumbs = zapcore.LevelOf(h.core.breadcrumbLevel).String()
+	}
+
+	return payload
+}
+
+// update applies a PUT request. Nothing is changed if the request is
+// invalid.
+func (h levelHandler) update(r *http.Request) error {
+	req, err := decodeLevelRequest(r)
+	if err != nil {
+		return err
+	}
+
+	if req.Level == nil && req.Loggers == nil {
+		return errNoLevelChange
+	}
+
+	atomicLevel, isAtomic := h.core.LevelEnabler.(zap.AtomicLevel)
+	if req.Level != nil && !isAtomic {
+		return errLevelNotAtomic
+	}
+
+	if req.Loggers != nil {
+		if h.core.loggerLevels == nil {
+			return errLoggersNotSet
+		}
+
+		if err := h.core.loggerLevels.SetMap(*req.Loggers); err != nil {
+			return err
+		}
+	}
+
+	if req.Level != nil {
+		atomicLevel.SetLevel(*req.Level)
+	}
+
+	return nil
+}
+
+// decodeLevelRequest reads a PUT request from a form or a JSON body.
+func decodeLevelRequest(r *http.Request) (levelRequest, error) {
+	var req levelRequest
+
+	if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			return req, fmt.Errorf("malformed request body: %w", err)
+		}
+
+		return req, nil
+	}
+
+	if text := r.FormValue("level"); text != "" {
+		level, err := zapcore.ParseLevel(text)
+		if err != nil {
+			return req, err
+		}
+
+		req.Level = &level
+	}
+
+	if _, ok := r.Form["loggers"]; ok {
+		loggers, err := ParseLoggerLevels(r.FormValue("loggers"))
+		if err != nil {
+			return req, err
+		}
+
+		rules := loggers.Map()
+		req.Loggers = &rules
+	}
+
+	return req, nil
+}
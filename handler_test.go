@@ -0,0 +1,120 @@
+package sentryzapcore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// serveLevel sends a request to the handler and decodes the JSON response.
+func serveLevel(t *testing.T, handler http.Handler, method, contentType, body string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, "/sentry/level", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+
+	return rec.Code, payload
+}
+
+func TestLevelHandler(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	loggers, err := ParseLoggerLevels("db=warn")
+	require.NoError(t, err)
+
+	core := NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithAtomicLevel(zap.NewAtomicLevelAt(zapcore.ErrorLevel)),
+		WithLoggerLevels(loggers),
+		WithEventLevel(zapcore.DPanicLevel),
+	)
+	logger := zap.New(core)
+	handler := core.LevelHandler()
+
+	sent := func(l *zap.Logger, level zapcore.Level) bool {
+		message := gofakeit.Sentence()
+		l.Log(level, message)
+		hub.Flush(2 * time.Second)
+
+		_, found := findLog(transport.Events(), message)
+
+		return found
+	}
+
+	code, payload := serveLevel(t, handler, http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, map[string]interface{}{
+		"level":   "error",
+		"loggers": map[string]interface{}{"db": "warn"},
+		"events":  "dpanic",
+	}, payload)
+
+	require.False(t, sent(logger, zapcore.InfoLevel))
+	require.True(t, sent(logger.Named("db"), zapcore.WarnLevel))
+
+	code, payload = serveLevel(t, handler, http.MethodPut, "application/x-www-form-urlencoded",
+		"level=info&loggers=db%3Derror")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "info", payload["level"])
+	require.Equal(t, map[string]interface{}{"db": "error"}, payload["loggers"])
+
+	require.True(t, sent(logger, zapcore.InfoLevel))
+	require.False(t, sent(logger.Named("db"), zapcore.WarnLevel))
+
+	code, payload = serveLevel(t, handler, http.MethodPut, "application/json",
+		`{"loggers":{"db":"debug","*":"warn"}}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "info", payload["level"])
+	require.Equal(t, map[string]interface{}{"db": "debug", "*": "warn"}, payload["loggers"])
+
+	require.True(t, sent(logger.Named("db"), zapcore.DebugLevel))
+	require.False(t, sent(logger, zapcore.InfoLevel))
+
+	for _, body := range []string{`{"level":"loud"}`, `{"loggers":{"db":"loud"}}`, `{}`, `{`} {
+		code, payload = serveLevel(t, handler, http.MethodPut, "application/json", body)
+		require.Equal(t, http.StatusBadRequest, code, body)
+		require.NotEmpty(t, payload["error"], body)
+	}
+
+	code, _ = serveLevel(t, handler, http.MethodPost, "", "")
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+
+	// Invalid requests change nothing.
+	_, payload = serveLevel(t, handler, http.MethodGet, "", "")
+	require.Equal(t, "info", payload["level"])
+	require.Equal(t, map[string]interface{}{"db": "debug", "*": "warn"}, payload["loggers"])
+}
+
+func TestLevelHandlerFixedLevels(t *testing.T) {
+	core := NewSentryCore(context.Background(), WithMinLevel(zapcore.WarnLevel), WithBreadcrumbs(zapcore.InfoLevel, 0))
+	handler := core.LevelHandler()
+
+	_, payload := serveLevel(t, handler, http.MethodGet, "", "")
+	require.Equal(t, map[string]interface{}{"level": "warn", "breadcrumbs": "info"}, payload)
+
+	code, payload := serveLevel(t, handler, http.MethodPut, "application/json", `{"level":"debug"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Contains(t, payload["error"], "WithAtomicLevel")
+
+	code, payload = serveLevel(t, handler, http.MethodPut, "application/json", `{"loggers":{"db":"warn"}}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Contains(t, payload["error"], "WithLoggerLevels")
+}
@@ -0,0 +1,45 @@
+package sentryzapcore
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// hubFor returns the hub entries are sent through: the hub bound to the core
+// if any, otherwise the hub carried by ctx or the core context, falling back
+// to the current (global) hub.
+func (s *SentryCore) hubFor(ctx context.Context) *sentry.Hub {
+	if s.hub != nil {
+		return s.hub
+	}
+
+	return hubForContext(ctx, s.ctx)
+}
+
+// bindContext returns ctx with the core's bound hub set on it, so that the
+// sentry.Logger never resolves a different hub from an entry context.
+// It returns ctx unchanged when the core is not bound to a hub.
+func (s *SentryCore) bindContext(ctx context.Context) context.Context {
+	if s.hub == nil {
+		return ctx
+	}
+
+	return sentry.SetHubOnContext(ctx, s.hub)
+}
+
+// hubForContext returns the first hub found in the given contexts, falling
+// back to the current (global) hub.
+func hubForContext(ctxs ...context.Context) *sentry.Hub {
+	for _, ctx := range ctxs {
+		if ctx == nil {
+			continue
+		}
+
+		if hub := sentry.GetHubFromContext(ctx); hub != nil {
+			return hub
+		}
+	}
+
+	return sentry.CurrentHub()
+}
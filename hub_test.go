@@ -0,0 +1,72 @@
+package sentryzapcore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestHubBinding(t *testing.T) {
+	transportA := &transportMock{}
+	transportB := &transportMock{}
+	hubA := newTestHub(t, transportA)
+	hubB := newTestHub(t, transportB)
+
+	loggerA := zap.New(NewSentryCore(context.Background(), WithHub(hubA), WithEventLevel(zapcore.ErrorLevel)))
+	loggerB := zap.New(NewSentryCore(context.Background(), WithClient(hubB.Client()), WithEventLevel(zapcore.ErrorLevel)))
+
+	// A context carrying the other tenant's hub must not redirect entries.
+	foreignCtx := sentry.SetHubOnContext(context.Background(), hubB)
+	ctxField := zap.Field{Key: "ctx", Type: zapcore.SkipType, Interface: foreignCtx}
+
+	messageA := gofakeit.Sentence()
+	loggerA.Error(messageA, ctxField, zap.Error(errors.New("tenant a")))
+
+	childA := loggerA.With(ctxField, zap.String("tenant", "a"))
+	childMessageA := gofakeit.Sentence()
+	childA.Error(childMessageA)
+
+	messageB := gofakeit.Sentence()
+	loggerB.Error(messageB)
+
+	require.NoError(t, loggerA.Sync())
+	require.NoError(t, loggerB.Sync())
+
+	for _, message := range []string{messageA, childMessageA} {
+		_, found := findLog(transportA.Events(), message)
+		require.True(t, found)
+		_, found = findEvent(transportA.Events(), message)
+		require.True(t, found)
+
+		_, found = findLog(transportB.Events(), message)
+		require.False(t, found)
+		_, found = findEvent(transportB.Events(), message)
+		require.False(t, found)
+	}
+
+	_, found := findLog(transportB.Events(), messageB)
+	require.True(t, found)
+	_, found = findLog(transportA.Events(), messageB)
+	require.False(t, found)
+	_, found = findEvent(transportA.Events(), messageB)
+	require.False(t, found)
+}
+
+func TestHubBindingSync(t *testing.T) {
+	transportA := &transportMock{}
+	transportB := &transportMock{}
+	hubA := newTestHub(t, transportA)
+	newTestHub(t, transportB)
+
+	core := NewSentryCore(context.Background(), WithHub(hubA))
+	require.NoError(t, core.With([]zapcore.Field{zap.String("k", "v")}).Sync())
+
+	require.Positive(t, transportA.Flushes())
+	require.Zero(t, transportB.Flushes())
+}
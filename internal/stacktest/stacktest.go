@@ -0,0 +1,13 @@
+// Package stacktest helps the tests of sentryzapcore check parsed stacks.
+//
+// Frames of the sentryzapcore package are trimmed off the top of the stacks
+// sent to Sentry, and that includes its own tests. Logging through Call
+// leaves a frame from outside the package on top of the stack.
+package stacktest
+
+// Call calls f. Its frame is the top of the stack of anything f logs.
+//
+//go:noinline
+func Call(f func()) {
+	f()
+}
@@ -0,0 +1,201 @@
+package sentryzapcore
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultLoggerLevelKey is the key of the rule that applies to the loggers no
+// other rule matches.
+const defaultLoggerLevelKey = "*"
+
+// LoggerLevels is a table of minimum levels keyed by logger name prefix, set
+// with WithLoggerLevels. A rule for "db" applies to the "db" logger and its
+// children, such as "db.pool", and the longest matching prefix wins. The "*"
+// rule applies to loggers no other rule matches; without it, they keep the
+// level of the core.
+//
+// The rules can be replaced at runtime with Set and SetMap. The zero value
+// has no rules.
+type LoggerLevels struct {
+	table atomic.Pointer[loggerLevelTable]
+}
+
+// noLoggerLevels is the table of a nil or zero LoggerLevels.
+var noLoggerLevels = &loggerLevelTable{}
+
+// loggerLevelTable is an immutable set of rules.
+type loggerLevelTable struct {
+	rules        []loggerLevelRule // longest prefix first
+	defaultLevel *zapcore.Level    // level of the "*" rule, if any
+}
+
+// loggerLevelRule is the minimum level of a logger name prefix.
+type loggerLevelRule struct {
+	prefix string
+	level  zapcore.Level
+}
+
+// ParseLoggerLevels parses a comma-separated list of prefix=level rules, such
+// as "db=warn,http.client=debug,*=error".
+func ParseLoggerLevels(spec string) (*LoggerLevels, error) {
+	l := &LoggerLevels{}
+	if err := l.Set(spec); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// LoggerLevelsFromMap builds a LoggerLevels from a map of logger name prefix
+// to level name, such as {"db": "warn", "*": "error"}.
+func LoggerLevelsFromMap(levels map[string]string) (*LoggerLevels, error) {
+	l := &LoggerLevels{}
+	if err := l.SetMap(levels); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Set replaces the rules with the ones of a spec in the format read by
+// ParseLoggerLevels. The rules are left unchanged if the spec is invalid.
+func (l *LoggerLevels) Set(spec string) error {
+	levels := make(map[string]string)
+
+	for rule := range strings.SplitSeq(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		prefix, level, ok := strings.Cut(rule, "=")
+		if !ok {
+			return fmt.Errorf("sentryzapcore: logger level rule %q is not prefix=level", rule)
+		}
+
+		levels[strings.TrimSpace(prefix)] = strings.TrimSpace(level)
+	}
+
+	return l.SetMap(levels)
+}
+
+// SetMap replaces the rules with the ones of a map in the format read by
+// LoggerLevelsFromMap. The rules are left unchanged if the map is invalid.
+func (l *LoggerLevels) SetMap(levels map[string]string) error {
+	t := &loggerLevelTable{rules: make([]loggerLevelRule, 0, len(levels))}
+
+	for prefix, name := range levels {
+		if prefix == "" {
+			return fmt.Errorf("sentryzapcore: logger level rule %q has an empty logger name", prefix+"="+name)
+		}
+
+		level, err := zapcore.ParseLevel(name)
+		if err != nil {
+			return fmt.Errorf("sentryzapcore: logger level rule %q: %w", prefix+"="+name, err)
+		}
+
+		if prefix == defaultLoggerLevelKey {
+			t.defaultLevel = &level
+			continue
+		}
+
+		t.rules = append(t.rules, loggerLevelRule{prefix: prefix, level: level})
+	}
+
+	slices.SortFunc(t.rules, func(a, b loggerLevelRule) int {
+		if n := len(b.prefix) - len(a.prefix); n != 0 {
+			return n
+		}
+
+		return strings.Compare(a.prefix, b.prefix)
+	})
+
+	l.table.Store(t)
+
+	return nil
+}
+
+// Level returns the minimum level of the logger. It returns false when no
+// rule matches it.
+func (l *LoggerLevels) Level(loggerName string) (zapcore.Level, bool) {
+	t := l.load()
+
+	for _, rule := range t.rules {
+		if loggerNameHasPrefix(loggerName, rule.prefix) {
+			return rule.level, true
+		}
+	}
+
+	if t.defaultLevel != nil {
+		return *t.defaultLevel, true
+	}
+
+	return zapcore.InvalidLevel, false
+}
+
+// Map returns the rules in the format read by LoggerLevelsFromMap.
+func (l *LoggerLevels) Map() map[string]string {
+	t := l.load()
+
+	levels := make(map[string]string, len(t.rules)+1)
+	for _, rule := range t.rules {
+		levels[rule.prefix] = rule.level.String()
+	}
+
+	if t.defaultLevel != nil {
+		levels[defaultLoggerLevelKey] = t.defaultLevel.String()
+	}
+
+	return levels
+}
+
+// String returns the rules in the format read by ParseLoggerLevels.
+func (l *LoggerLevels) String() string {
+	t := l.load()
+
+	rules := make([]string, 0, len(t.rules)+1)
+	for _, rule := range t.rules {
+		rules = append(rules, rule.prefix+"="+rule.level.String())
+	}
+
+	if t.defaultLevel != nil {
+		rules = append(rules, defaultLoggerLevelKey+"="+t.defaultLevel.String())
+	}
+
+	return strings.Join(rules, ",")
+}
+
+// anyEnabled reports whether a rule enables the level.
+func (l *LoggerLevels) anyEnabled(level zapcore.Level) bool {
+	t := l.load()
+
+	if t.defaultLevel != nil && t.defaultLevel.Enabled(level) {
+		return true
+	}
+
+	for _, rule := range t.rules {
+		if rule.level.Enabled(level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// load returns the current rules. A nil or zero LoggerLevels has none.
+func (l *LoggerLevels) load() *loggerLevelTable {
+	if l == nil {
+		return noLoggerLevels
+	}
+
+	if t := l.table.Load(); t != nil {
+		return t
+	}
+
+	return noLoggerLevels
+}
@@ -0,0 +1,100 @@
+package sentryzapcore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseLoggerLevels(t *testing.T) {
+	levels, err := ParseLoggerLevels(" db=warn, http.client=debug,*=error,http=info ")
+	require.NoError(t, err)
+	require.Equal(t, "http.client=debug,http=info,db=warn,*=error", levels.String())
+
+	cases := map[string]zapcore.Level{
+		"db":               zapcore.WarnLevel,
+		"db.pool":          zapcore.WarnLevel,
+		"dbx":              zapcore.ErrorLevel,
+		"http":             zapcore.InfoLevel,
+		"http.client":      zapcore.DebugLevel,
+		"http.client.pool": zapcore.DebugLevel,
+		"http.server":      zapcore.InfoLevel,
+		"":                 zapcore.ErrorLevel,
+	}
+
+	for name, want := range cases {
+		level, ok := levels.Level(name)
+		require.True(t, ok, name)
+		require.Equal(t, want, level, name)
+	}
+
+	withoutDefault, err := LoggerLevelsFromMap(map[string]string{"db": "WARN"})
+	require.NoError(t, err)
+
+	_, ok := withoutDefault.Level("http")
+	require.False(t, ok)
+
+	for _, spec := range []string{"db", "db=loud", "=warn"} {
+		_, err := ParseLoggerLevels(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestLoggerLevels(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	levels, err := ParseLoggerLevels("db=warn,http.client=debug")
+	require.NoError(t, err)
+
+	console, observed := observer.New(zapcore.DebugLevel)
+	logger := zap.New(zapcore.NewTee(console, NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithLoggerLevels(levels),
+		WithEventLevel(zapcore.ErrorLevel),
+		WithBreadcrumbs(zapcore.DebugLevel, 10),
+	)))
+
+	sent := map[string]bool{}
+	log := func(name string, level zapcore.Level, want bool) string {
+		message := gofakeit.Sentence()
+		logger.Named(name).Log(level, message)
+		sent[message] = want
+
+		return message
+	}
+
+	log("http.client", zapcore.DebugLevel, true)
+	quietened := log("db", zapcore.InfoLevel, false)
+	log("db.pool", zapcore.WarnLevel, true)
+	breadcrumb := log("api", zapcore.WarnLevel, false)
+	failure := log("api", zapcore.ErrorLevel, true)
+
+	hub.Flush(2 * time.Second)
+
+	for message, want := range sent {
+		_, found := findLog(transport.Events(), message)
+		require.Equal(t, want, found, message)
+	}
+
+	// Entries below their logger's level do not become breadcrumbs either.
+	event, found := findEvent(transport.Events(), failure)
+	require.True(t, found)
+
+	var breadcrumbs []string
+	for _, b := range event.Breadcrumbs {
+		breadcrumbs = append(breadcrumbs, b.Message)
+	}
+
+	require.Contains(t, breadcrumbs, breadcrumb)
+	require.NotContains(t, breadcrumbs, quietened)
+
+	// Other cores still get every entry.
+	require.Equal(t, len(sent), observed.Len())
+}
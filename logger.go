@@ -15,9 +15,19 @@ func WithSentry(logger *zap.Logger, options ...SentryCoreOptions) *zap.Logger {
 
 // WithSentryOption returns a zap.Option that wraps the core with a SentryCore.
 // This is useful when you want to compose the option into a zap.Config
-// or a custom logger construction.
+// or a custom logger construction. The SentryCore cannot be closed, so
+// options with resources to release, such as WithAsync, need a core created
+// with NewSentryCore instead.
 func WithSentryOption(options ...SentryCoreOptions) zap.Option {
 	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
 		return zapcore.NewTee(core, NewSentryCore(context.Background(), options...))
 	})
 }
+
+// WithRouterOption returns a zap.Option that tees the core with the given
+// RouterCore, so that entries are routed to several Sentry destinations.
+func WithRouterOption(router *RouterCore) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, router)
+	})
+}
@@ -1,12 +1,20 @@
 package sentryzapcore
 
-import "go.uber.org/zap/zapcore"
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/getsentry/sentry-go/attribute"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
 
 // SentryCoreOptions is a functional option for configuring SentryCore.
 type SentryCoreOptions func(*SentryCore)
 
 // WithStackTrace enables inclusion of stack traces in Sentry log entries
-// when the log level is Error or above.
+// when the log level is Error or above. Events captured with WithEventLevel
+// get the stack parsed into frames.
 func WithStackTrace() SentryCoreOptions {
 	return func(s *SentryCore) {
 		s.stackTrace = true
@@ -20,3 +28,216 @@ func WithMinLevel(level zapcore.Level) SentryCoreOptions {
 		s.LevelEnabler = level
 	}
 }
+
+// WithAtomicLevel sets the minimum log level for sending entries to Sentry,
+// like WithMinLevel, to a level that can be changed at runtime, for example
+// through LevelHandler.
+func WithAtomicLevel(level zap.AtomicLevel) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.LevelEnabler = level
+	}
+}
+
+// WithEventLevel enables capturing a Sentry event (which opens an Issue) for
+// every entry at or above the given level, in addition to the structured log.
+// The event carries the message, the mapped level, the fields under the
+// "fields" context and an exception built from any zap.Error field.
+func WithEventLevel(level zapcore.Level) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.eventLevel = level
+	}
+}
+
+// WithBreadcrumbs records entries at or above the given level that are not
+// sent to Sentry as logs or events as breadcrumbs on the current hub scope,
+// so that a later event carries the trail that led up to it. maxBreadcrumbs
+// caps the scope's breadcrumb ring buffer; zero or less uses the client's
+// MaxBreadcrumbs setting.
+func WithBreadcrumbs(level zapcore.Level, maxBreadcrumbs int) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.breadcrumbLevel = level
+		s.maxBreadcrumbs = maxBreadcrumbs
+	}
+}
+
+// WithHub binds the core to the given hub instead of the hub found in the
+// context passed to NewSentryCore (or the global hub). Logs, events and
+// breadcrumbs are sent through the hub's client and Sync flushes only that
+// client. Cores derived via With keep the binding.
+func WithHub(hub *sentry.Hub) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.hub = hub
+	}
+}
+
+// WithClient binds the core to a new hub for the given client.
+// See WithHub.
+func WithClient(client *sentry.Client) SentryCoreOptions {
+	return WithHub(sentry.NewHub(client, sentry.NewScope()))
+}
+
+// WithFieldSeparator sets the separator used to join the keys of nested
+// zap.Object, zap.Array and zap.Namespace fields. It defaults to ".".
+func WithFieldSeparator(separator string) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.fieldSeparator = separator
+	}
+}
+
+// WithMaxFieldDepth sets how many nesting levels of zap.Object, zap.Array and
+// zap.Namespace fields are flattened into separate attributes. Deeper values
+// are reported as a single attribute. It defaults to 10; zero disables
+// flattening.
+func WithMaxFieldDepth(depth int) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.maxFieldDepth = depth
+	}
+}
+
+// WithValueConverter registers converters for application-specific field
+// value types. Converters run in registration order, ahead of the built-in
+// conversions, for both With fields and entry fields.
+func WithValueConverter(converters ...ValueConverter) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.converters = append(s.converters, converters...)
+	}
+}
+
+// WithScrubber redacts sensitive attributes, messages, stack traces,
+// exception values and the values of Tag, User and Contexts with the given
+// Scrubber before anything is sent to Sentry. See NewScrubber.
+func WithScrubber(scrubber *Scrubber) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.scrubber = scrubber
+	}
+}
+
+// WithBeforeEmit registers a hook that can rewrite the entry (message,
+// level, logger name, caller, ...) and its attributes, or veto sending it,
+// right before the core sends it to Sentry as a log, event or breadcrumb.
+// Unlike sentry.ClientOptions.BeforeSendLog, the hook sees zap's Entry.
+// Hooks run in registration order.
+func WithBeforeEmit(hook BeforeEmitFunc) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.beforeEmit = append(s.beforeEmit, hook)
+	}
+}
+
+// WithAttributes adds static attributes to every entry the core sends to
+// Sentry, for example the deployment that produced it.
+func WithAttributes(attrs ...attribute.Builder) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.attributes = append(s.attributes, attrs...)
+	}
+}
+
+// WithResourceAttributes adds the attributes detected by ResourceAttributes
+// (host, process, build and Kubernetes information) to every entry.
+func WithResourceAttributes() SentryCoreOptions {
+	return WithAttributes(ResourceAttributes()...)
+}
+
+// WithInAppPrefixes marks stack frames whose module starts with one of the
+// prefixes as in_app in the stack traces attached to captured events (see
+// WithStackTrace and WithEventLevel); all other frames are not in_app.
+// Without prefixes, frames outside the standard library and vendored
+// packages are in_app.
+func WithInAppPrefixes(prefixes ...string) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.inAppPrefixes = append(s.inAppPrefixes, prefixes...)
+	}
+}
+
+// WithRateLimit limits the entries sent to Sentry with a token bucket per
+// key: perSecond entries a second, with bursts of up to burst entries. key
+// defaults to RateLimitByMessage. DPanic, Panic and Fatal entries are never
+// limited, nor are entries only kept as breadcrumbs. The number of suppressed
+// entries per key is reported every minute, even if nothing is logged
+// afterwards, and on Sync, as a structured log such as "suppressed 4,812
+// occurrences of "db timeout" in last 1m0s", when the core logs the level of
+// the suppressed entries for their logger.
+// The limit is shared by the cores derived via With.
+func WithRateLimit(perSecond float64, burst int, key RateLimitKeyFunc) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.rateLimiter = newRateLimiter(perSecond, burst, key)
+	}
+}
+
+// WithDeduplication merges identical entries, with the same level, message,
+// logger name, values of the given key fields (from the entry or from With)
+// and fields added via With, into a single entry as long as each one is
+// logged within window of the previous one. The entry is
+// sent once window has elapsed without another occurrence, after ten windows
+// at most, or on Sync, with the "occurrences", "first_seen" and "last_seen"
+// attributes. Every entry, even a unique one, is thus sent at least window
+// after it was logged; this includes captured events. Deduplication is shared
+// by the cores derived via With.
+func WithDeduplication(window time.Duration, keyFields ...string) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.deduplicator = newDeduplicator(window, keyFields)
+	}
+}
+
+// WithAsync moves the encoding and sending of entries off the logging
+// goroutine: Write queues the entry in a bounded queue of queueSize entries
+// (1024 if zero or less) and workers goroutines (at least one) send queued
+// entries in batches. policy selects what happens when the queue is full;
+// AsyncStats reports the drops. With one worker, entries logged by a
+// goroutine are sent in order. Field values are encoded on a worker, so they
+// must not be mutated after logging. Sync waits for the queued entries; Close
+// stops the workers.
+//
+// The workers start with NewSentryCore and run until Close. Close is only
+// reachable on a core you construct yourself: the cores built by WithSentry
+// and WithSentryOption are hidden inside a tee, so their workers run for the
+// life of the process. Pass such a core to zap.New or zap.WrapCore instead.
+func WithAsync(queueSize, workers int, policy OverflowPolicy) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.async = newAsyncWriter(queueSize, workers, policy)
+	}
+}
+
+// WithFlushTimeout sets how long Sync waits for entries to be delivered to
+// Sentry. It defaults to 2 seconds; use SyncContext for a deadline of its
+// own.
+func WithFlushTimeout(timeout time.Duration) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.flushTimeout = timeout
+	}
+}
+
+// WithPanicFlushTimeout sets how long writing a DPanic, Panic or Fatal entry
+// waits for it, and the entries before it, to be delivered to Sentry, since
+// zap may panic or exit the process right after. It defaults to 2 seconds.
+func WithPanicFlushTimeout(timeout time.Duration) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.panicFlushTimeout = timeout
+	}
+}
+
+// LevelMapper returns the Sentry severity of entries at a zap level. It may
+// be given levels outside the range of the zap constants, for custom levels.
+type LevelMapper func(level zapcore.Level) sentry.LogLevel
+
+// WithLevelMapper sets the severity of structured logs for each zap level.
+// Captured events and breadcrumbs get the closest sentry.Level, trace being
+// reported as debug. By default, Error, DPanic, Panic and Fatal logs are all
+// sent as errors. The zap level itself is always kept, as the "zap.level"
+// attribute of logs and tag of events. A Level field takes precedence over
+// the mapper.
+func WithLevelMapper(mapper LevelMapper) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.levelMapper = mapper
+	}
+}
+
+// WithLoggerLevels sets minimum levels per logger name prefix, in place of
+// the level set with WithMinLevel for the loggers they match. Entries of
+// those loggers below their level are neither logged nor captured as events
+// or breadcrumbs. Other cores, such as a console one, are not affected. The
+// rules can be changed at runtime, directly or through LevelHandler.
+func WithLoggerLevels(levels *LoggerLevels) SentryCoreOptions {
+	return func(s *SentryCore) {
+		s.loggerLevels = levels
+	}
+}
@@ -0,0 +1,237 @@
+package sentryzapcore
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// rateLimitSummaryInterval is how often the number of suppressed entries
+	// is reported.
+	rateLimitSummaryInterval = time.Minute
+	// maxRateLimitKeys bounds the number of keys tracked by a rate limiter.
+	// Entries with new keys are not limited once it is reached.
+	maxRateLimitKeys = 10000
+)
+
+// RateLimitKeyFunc returns the key an entry is rate limited by. Entries with
+// the same key share a token bucket.
+type RateLimitKeyFunc func(entry zapcore.Entry) string
+
+// RateLimitByMessage rate limits entries per message.
+func RateLimitByMessage(entry zapcore.Entry) string {
+	return entry.Message
+}
+
+// RateLimitByLogger rate limits entries per logger name.
+func RateLimitByLogger(entry zapcore.Entry) string {
+	return entry.LoggerName
+}
+
+// rateLimiter limits entries per key with token buckets, and counts the
+// entries it suppresses. It is shared by the cores derived via With.
+type rateLimiter struct {
+	rate     float64 // tokens added per second
+	burst    float64
+	key      RateLimitKeyFunc
+	now      func() time.Time
+	interval time.Duration // between summaries
+
+	mu        sync.Mutex
+	buckets   map[string]*rateLimitBucket
+	lastSweep time.Time
+	timer     *time.Timer // reports pending summaries if no entry does
+}
+
+// rateLimitBucket is the token bucket of a key.
+type rateLimitBucket struct {
+	tokens     float64
+	updated    time.Time
+	suppressed int64
+	since      time.Time   // start of the current summary window
+	core       *SentryCore // core of the last suppressed entry
+	entry      zapcore.Entry
+}
+
+// rateLimitSummary reports the entries suppressed for a key.
+type rateLimitSummary struct {
+	core       *SentryCore
+	key        string
+	entry      zapcore.Entry // last suppressed entry
+	suppressed int64
+	window     time.Duration
+}
+
+func newRateLimiter(perSecond float64, burst int, key RateLimitKeyFunc) *rateLimiter {
+	if key == nil {
+		key = RateLimitByMessage
+	}
+
+	return &rateLimiter{
+		rate:     perSecond,
+		burst:    float64(burst),
+		key:      key,
+		now:      time.Now,
+		interval: rateLimitSummaryInterval,
+		buckets:  make(map[string]*rateLimitBucket),
+	}
+}
+
+// allow reports whether the entry, written by core, may be sent. It also
+// returns the summaries that are due. A nil limiter allows everything.
+func (r *rateLimiter) allow(core *SentryCore, entry zapcore.Entry) (bool, []rateLimitSummary) {
+	if r == nil {
+		return true, nil
+	}
+
+	key := r.key(entry)
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var summaries []rateLimitSummary
+	if now.Sub(r.lastSweep) >= r.interval {
+		summaries = r.sweep(now)
+	}
+
+	b, ok := r.buckets[key]
+	if !ok {
+		if len(r.buckets) >= maxRateLimitKeys {
+			return true, summaries
+		}
+
+		b = &rateLimitBucket{tokens: r.burst, updated: now, since: now}
+		r.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.updated).Seconds() * r.rate
+	if b.tokens > r.burst {
+		b.tokens = r.burst
+	}
+
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, summaries
+	}
+
+	b.suppressed++
+	b.core = core
+	b.entry = entry
+
+	if r.timer == nil {
+		r.timer = time.AfterFunc(r.lastSweep.Add(r.interval).Sub(now), r.report)
+	}
+
+	return false, summaries
+}
+
+// report emits the summaries that are due when the logger went quiet after
+// suppressing entries.
+func (r *rateLimiter) report() {
+	r.mu.Lock()
+	summaries := r.sweep(r.now())
+	r.mu.Unlock()
+
+	emitRateLimitSummaries(summaries)
+}
+
+// flush returns the summaries of every key with suppressed entries.
+func (r *rateLimiter) flush() []rateLimitSummary {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sweep(r.now())
+}
+
+// sweep returns the summaries of the keys with suppressed entries, starts a
+// new summary window and forgets the keys whose bucket is full again.
+// r.mu must be held.
+func (r *rateLimiter) sweep(now time.Time) []rateLimitSummary {
+	var summaries []rateLimitSummary
+
+	for key, b := range r.buckets {
+		if b.suppressed > 0 {
+			summaries = append(summaries, rateLimitSummary{
+				core:       b.core,
+				key:        key,
+				entry:      b.entry,
+				suppressed: b.suppressed,
+				window:     now.Sub(b.since),
+			})
+		} else if b.tokens+now.Sub(b.updated).Seconds()*r.rate >= r.burst {
+			delete(r.buckets, key)
+			continue
+		}
+
+		b.suppressed = 0
+		b.since = now
+		b.core = nil
+	}
+
+	r.lastSweep = now
+
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+
+	return summaries
+}
+
+// emitRateLimitSummaries sends each summary as a structured log, through
+// the core of the last suppressed entry, if that core logs the entry's level
+// for its logger and its WithBeforeEmit hooks keep the summary. Summaries are
+// not captured as events, so that they do not open an Issue per count.
+func emitRateLimitSummaries(summaries []rateLimitSummary) {
+	for _, summary := range summaries {
+		key := summary.core.scrubber.scrubString(summary.key)
+
+		entry := zapcore.Entry{
+			Level:      summary.entry.Level,
+			Time:       time.Now(),
+			LoggerName: summary.entry.LoggerName,
+			Message: fmt.Sprintf("suppressed %s occurrences of %q in last %s",
+				formatCount(summary.suppressed), key, summary.window.Round(time.Second)),
+		}
+
+		if logged, _ := summary.core.logTargets(entry); !logged {
+			continue
+		}
+
+		entry, values, send := summary.core.runBeforeEmit(entry, map[string]interface{}{
+			"rate_limit.key":        key,
+			"rate_limit.suppressed": summary.suppressed,
+		})
+		if !send {
+			continue
+		}
+
+		summary.core.emitLog(entry, encodedFields{values: values})
+	}
+}
+
+// formatCount formats n with thousands separators, as in "4,812".
+func formatCount(n int64) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+
+	s := strconv.FormatInt(n, 10)
+
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+
+	return s
+}
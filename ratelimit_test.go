@@ -0,0 +1,237 @@
+package sentryzapcore
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func countLogs(events []*sentry.Event, message string) int {
+	var n int
+
+	for _, event := range events {
+		for _, log := range event.Logs {
+			if log.Body == message {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+func findLogPrefix(events []*sentry.Event, prefix string) (*sentry.Log, bool) {
+	for _, event := range events {
+		for i := range event.Logs {
+			if strings.HasPrefix(event.Logs[i].Body, prefix) {
+				return &event.Logs[i], true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func TestRateLimit(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithRateLimit(1, 3, nil))
+
+	now := time.Now()
+	core.rateLimiter.now = func() time.Time { return now }
+
+	logger := zap.New(core).Named("db")
+
+	flooded := gofakeit.Sentence()
+	other := gofakeit.Sentence()
+
+	for range 5000 {
+		logger.Error(flooded)
+	}
+
+	logger.Error(other)
+
+	now = now.Add(2 * time.Second)
+	logger.Error(flooded)
+	logger.Error(flooded)
+	logger.Error(flooded)
+
+	require.NoError(t, core.Sync())
+	hub.Flush(2 * time.Second)
+
+	require.Equal(t, 5, countLogs(transport.Events(), flooded))
+	require.Equal(t, 1, countLogs(transport.Events(), other))
+
+	summary, found := findLogPrefix(transport.Events(), "suppressed 4,998 occurrences of \""+flooded+"\" in last ")
+	require.True(t, found)
+	require.Equal(t, sentry.LogLevelError, summary.Level)
+	require.Equal(t, "db", summary.Attributes["logger"].String())
+	require.Equal(t, flooded, summary.Attributes["rate_limit.key"].String())
+	require.Equal(t, int64(4998), summary.Attributes["rate_limit.suppressed"].AsInt64())
+
+	_, found = findLogPrefix(transport.Events(), "suppressed 0 ")
+	require.False(t, found)
+}
+
+func TestRateLimitIdleSummary(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithRateLimit(0, 1, nil))
+	core.rateLimiter.interval = 50 * time.Millisecond
+
+	logger := zap.New(core)
+	flooded := gofakeit.Sentence()
+
+	for range 3 {
+		logger.Error(flooded)
+	}
+
+	// Nothing is logged after the flood; the summary is still reported.
+	require.Eventually(t, func() bool {
+		hub.Flush(2 * time.Second)
+
+		_, found := findLogPrefix(transport.Events(), "suppressed 2 occurrences of \""+flooded+"\" in last ")
+
+		return found
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, core.Close())
+
+	core.rateLimiter.mu.Lock()
+	defer core.rateLimiter.mu.Unlock()
+	require.Nil(t, core.rateLimiter.timer)
+}
+
+func TestRateLimitSummaryInterval(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithMinLevel(zapcore.WarnLevel), WithRateLimit(0, 1, RateLimitByLogger))
+
+	now := time.Now()
+	core.rateLimiter.now = func() time.Time { return now }
+
+	logger := zap.New(core).Named("worker").With(zap.String("shard", "a"))
+
+	// Concurrent writes through cores derived via With share the limit.
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			logger.With(zap.Int("n", 1)).Warn(gofakeit.Sentence())
+		})
+	}
+
+	wg.Wait()
+
+	now = now.Add(rateLimitSummaryInterval)
+	logger.Named("other").Warn(gofakeit.Sentence())
+	hub.Flush(2 * time.Second)
+
+	summary, found := findLogPrefix(transport.Events(), `suppressed 9 occurrences of "worker" in last 1m0s`)
+	require.True(t, found)
+	require.Equal(t, sentry.LogLevelWarn, summary.Level)
+	require.Equal(t, "a", summary.Attributes["shard"].String())
+}
+
+func TestRateLimitGate(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	t.Run("breadcrumbs are not limited", func(t *testing.T) {
+		core := NewSentryCore(context.Background(),
+			WithHub(hub),
+			WithBreadcrumbs(zapcore.DebugLevel, 0),
+			WithRateLimit(0, 1, nil),
+		)
+		logger := zap.New(core)
+		debug := gofakeit.Sentence()
+
+		for range 5 {
+			logger.Debug(debug)
+		}
+
+		require.NoError(t, core.Sync())
+		hub.Flush(2 * time.Second)
+
+		_, found := findLogPrefix(transport.Events(), "suppressed 4 occurrences of \""+debug+"\"")
+		require.False(t, found)
+		require.Empty(t, core.rateLimiter.buckets)
+	})
+
+	t.Run("summaries go through the hooks", func(t *testing.T) {
+		core := NewSentryCore(context.Background(),
+			WithHub(hub),
+			WithRateLimit(0, 1, nil),
+			WithBeforeEmit(func(entry zapcore.Entry, attrs map[string]interface{}) (zapcore.Entry, map[string]interface{}, bool) {
+				return entry, attrs, !strings.HasPrefix(entry.Message, "suppressed ")
+			}),
+		)
+		logger := zap.New(core)
+		flooded := gofakeit.Sentence()
+
+		for range 5 {
+			logger.Error(flooded)
+		}
+
+		require.NoError(t, core.Sync())
+		hub.Flush(2 * time.Second)
+
+		require.Equal(t, 1, countLogs(transport.Events(), flooded))
+
+		_, found := findLogPrefix(transport.Events(), "suppressed 4 occurrences of \""+flooded+"\"")
+		require.False(t, found)
+	})
+
+	t.Run("summaries of levels that are not logged", func(t *testing.T) {
+		core := NewSentryCore(context.Background(),
+			WithHub(hub),
+			WithMinLevel(zapcore.DPanicLevel),
+			WithEventLevel(zapcore.ErrorLevel),
+			WithRateLimit(0, 1, nil),
+		)
+		logger := zap.New(core)
+		flooded := gofakeit.Sentence()
+
+		for range 5 {
+			logger.Error(flooded)
+		}
+
+		require.NoError(t, core.Sync())
+		hub.Flush(2 * time.Second)
+
+		_, found := findEvent(transport.Events(), flooded)
+		require.True(t, found)
+
+		_, found = findLogPrefix(transport.Events(), "suppressed 4 occurrences of \""+flooded+"\"")
+		require.False(t, found)
+	})
+}
+
+func TestFormatCount(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:        "0",
+		999:      "999",
+		1000:     "1,000",
+		4812:     "4,812",
+		1234567:  "1,234,567",
+		-1234567: "-1,234,567",
+	} {
+		require.Equal(t, want, formatCount(n))
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	allowed, summaries := (*rateLimiter)(nil).allow(nil, zapcore.Entry{})
+	require.True(t, allowed)
+	require.Nil(t, summaries)
+}
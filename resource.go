@@ -0,0 +1,125 @@
+package sentryzapcore
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/getsentry/sentry-go/attribute"
+)
+
+// kubernetesNamespaceFile holds the pod namespace when a service account
+// token is mounted.
+const kubernetesNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// ResourceAttributes returns every attribute the resource helpers detect:
+// HostAttributes, ProcessAttributes, BuildAttributes and
+// KubernetesAttributes. Pass the result to WithAttributes, or use
+// WithResourceAttributes.
+func ResourceAttributes() []attribute.Builder {
+	var attrs []attribute.Builder
+
+	attrs = append(attrs, HostAttributes()...)
+	attrs = append(attrs, ProcessAttributes()...)
+	attrs = append(attrs, BuildAttributes()...)
+	attrs = append(attrs, KubernetesAttributes()...)
+
+	return attrs
+}
+
+// HostAttributes returns the "host.name" attribute, if the hostname is known.
+func HostAttributes() []attribute.Builder {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return nil
+	}
+
+	return []attribute.Builder{attribute.String("host.name", hostname)}
+}
+
+// ProcessAttributes returns the "process.pid", "process.runtime.name" and
+// "process.runtime.version" attributes.
+func ProcessAttributes() []attribute.Builder {
+	return []attribute.Builder{
+		attribute.Int("process.pid", os.Getpid()),
+		attribute.String("process.runtime.name", "go"),
+		attribute.String("process.runtime.version", runtime.Version()),
+	}
+}
+
+// BuildAttributes returns the main module path and version and the VCS
+// revision, time and modified flag recorded by the Go toolchain, read with
+// debug.ReadBuildInfo. It returns nil when no build info is embedded.
+func BuildAttributes() []attribute.Builder {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	return buildAttributes(info)
+}
+
+func buildAttributes(info *debug.BuildInfo) []attribute.Builder {
+	var attrs []attribute.Builder
+
+	if info.Main.Path != "" {
+		attrs = append(attrs, attribute.String("module.path", info.Main.Path))
+	}
+
+	if info.Main.Version != "" {
+		attrs = append(attrs, attribute.String("module.version", info.Main.Version))
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision", "vcs.time":
+			attrs = append(attrs, attribute.String(setting.Key, setting.Value))
+		case "vcs.modified":
+			attrs = append(attrs, attribute.Bool(setting.Key, setting.Value == "true"))
+		}
+	}
+
+	return attrs
+}
+
+// KubernetesAttributes returns the "k8s.pod.name", "k8s.namespace.name" and
+// "k8s.node.name" attributes from the POD_NAME, POD_NAMESPACE and NODE_NAME
+// environment variables, as usually exposed through the downward API. Inside
+// a cluster, the pod name falls back to HOSTNAME and the namespace to the
+// mounted service account namespace.
+func KubernetesAttributes() []attribute.Builder {
+	return kubernetesAttributes(os.Getenv, os.ReadFile)
+}
+
+func kubernetesAttributes(getenv func(string) string, readFile func(string) ([]byte, error)) []attribute.Builder {
+	inCluster := getenv("KUBERNETES_SERVICE_HOST") != ""
+
+	pod := getenv("POD_NAME")
+	if pod == "" && inCluster {
+		pod = getenv("HOSTNAME")
+	}
+
+	namespace := getenv("POD_NAMESPACE")
+	if namespace == "" && inCluster {
+		if b, err := readFile(kubernetesNamespaceFile); err == nil {
+			namespace = strings.TrimSpace(string(b))
+		}
+	}
+
+	var attrs []attribute.Builder
+
+	if pod != "" {
+		attrs = append(attrs, attribute.String("k8s.pod.name", pod))
+	}
+
+	if namespace != "" {
+		attrs = append(attrs, attribute.String("k8s.namespace.name", namespace))
+	}
+
+	if node := getenv("NODE_NAME"); node != "" {
+		attrs = append(attrs, attribute.String("k8s.node.name", node))
+	}
+
+	return attrs
+}
@@ -0,0 +1,94 @@
+package sentryzapcore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go/attribute"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func attributeMap(attrs []attribute.Builder) map[string]interface{} {
+	m := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		m[attr.Key] = attr.Value.AsInterface()
+	}
+
+	return m
+}
+
+func TestWithAttributes(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	logger := zap.New(NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithAttributes(attribute.String("deployment", "blue"), attribute.Int("shard", 3)),
+		WithResourceAttributes(),
+	)).With(zap.String("component", "api"))
+
+	message := gofakeit.Sentence()
+	logger.Error(message)
+	hub.Flush(2 * time.Second)
+
+	logEntry, found := findLog(transport.Events(), message)
+	require.True(t, found)
+	require.Equal(t, "blue", logEntry.Attributes["deployment"].String())
+	require.Equal(t, int64(3), logEntry.Attributes["shard"].AsInt64())
+	require.Equal(t, "api", logEntry.Attributes["component"].String())
+	require.Equal(t, int64(os.Getpid()), logEntry.Attributes["process.pid"].AsInt64())
+	require.Equal(t, runtime.Version(), logEntry.Attributes["process.runtime.version"].String())
+}
+
+func TestBuildAttributes(t *testing.T) {
+	info := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.modified", Value: "true"},
+			{Key: "GOOS", Value: "linux"},
+		},
+	}
+
+	require.Equal(t, map[string]interface{}{
+		"module.path":    "example.com/app",
+		"module.version": "v1.2.3",
+		"vcs.revision":   "abc123",
+		"vcs.modified":   true,
+	}, attributeMap(buildAttributes(info)))
+}
+
+func TestKubernetesAttributes(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+	readNamespace := func(string) ([]byte, error) { return []byte("payments\n"), nil }
+	noFile := func(string) ([]byte, error) { return nil, errors.New("not found") }
+
+	require.Empty(t, kubernetesAttributes(env(nil), readNamespace))
+
+	require.Equal(t, map[string]interface{}{
+		"k8s.pod.name":       "api-7d9f",
+		"k8s.namespace.name": "default",
+		"k8s.node.name":      "node-1",
+	}, attributeMap(kubernetesAttributes(env(map[string]string{
+		"POD_NAME":      "api-7d9f",
+		"POD_NAMESPACE": "default",
+		"NODE_NAME":     "node-1",
+	}), noFile)))
+
+	require.Equal(t, map[string]interface{}{
+		"k8s.pod.name":       "api-abc",
+		"k8s.namespace.name": "payments",
+	}, attributeMap(kubernetesAttributes(env(map[string]string{
+		"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+		"HOSTNAME":                "api-abc",
+	}), readNamespace)))
+}
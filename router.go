@@ -0,0 +1,248 @@
+package sentryzapcore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Ensure RouterCore implements zapcore.Core interface.
+var _ zapcore.Core = (*RouterCore)(nil)
+
+// Route selects the core an entry is sent to. All non-empty conditions must
+// match for the route to apply.
+type Route struct {
+	// LoggerPrefix matches entries whose logger name equals the prefix or
+	// is a child of it (for example "payments" matches "payments.refunds").
+	LoggerPrefix string
+	// Level matches entries whose level it enables.
+	Level zapcore.LevelEnabler
+	// FieldKey and FieldValue match entries carrying a field (on the entry
+	// or added via With) whose value formats to FieldValue.
+	FieldKey   string
+	FieldValue string
+	// Core receives the matching entries, typically a *SentryCore bound to
+	// its own client with WithClient.
+	Core zapcore.Core
+}
+
+// matchesEntry reports whether the logger name and level of the entry match
+// the route.
+func (r *Route) matchesEntry(entry zapcore.Entry) bool {
+	if r.LoggerPrefix != "" && !loggerNameHasPrefix(entry.LoggerName, r.LoggerPrefix) {
+		return false
+	}
+
+	return r.Level == nil || r.Level.Enabled(entry.Level)
+}
+
+// matches reports whether the route applies to the entry and its field values.
+func (r *Route) matches(entry zapcore.Entry, values ...map[string]interface{}) bool {
+	if !r.matchesEntry(entry) {
+		return false
+	}
+
+	if r.FieldKey == "" {
+		return true
+	}
+
+	for _, vals := range values {
+		if v, ok := vals[r.FieldKey]; ok && fmt.Sprint(v) == r.FieldValue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RouterCore is a zapcore.Core that sends each entry to the core of the first
+// matching Route, or to the default core when no route matches. It lets a
+// single logger report to several Sentry projects.
+type RouterCore struct {
+	routes      []Route
+	defaultCore zapcore.Core
+	values      map[string]interface{} // field values accumulated via With
+}
+
+// NewRouterCore creates a RouterCore. Routes are evaluated in order; entries
+// matching none of them go to defaultCore, or are dropped if it is nil.
+func NewRouterCore(defaultCore zapcore.Core, routes ...Route) *RouterCore {
+	return &RouterCore{
+		routes:      append([]Route(nil), routes...),
+		defaultCore: defaultCore,
+	}
+}
+
+// Enabled reports whether any of the routed cores handles the given level.
+// It implements the zapcore.LevelEnabler interface.
+func (r *RouterCore) Enabled(level zapcore.Level) bool {
+	if r.defaultCore != nil && r.defaultCore.Enabled(level) {
+		return true
+	}
+
+	for i := range r.routes {
+		if r.routes[i].Core.Enabled(level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// With adds structured context to every routed core and remembers the field
+// values for FieldKey matching.
+// It implements the zapcore.Core interface.
+func (r *RouterCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &RouterCore{
+		routes: make([]Route, len(r.routes)),
+		values: make(map[string]interface{}, len(r.values)+len(fields)),
+	}
+
+	for k, v := range r.values {
+		clone.values[k] = v
+	}
+
+	for k, v := range encodeFields(fields, nil).values {
+		clone.values[k] = v
+	}
+
+	for i, route := range r.routes {
+		route.Core = route.Core.With(fields)
+		clone.routes[i] = route
+	}
+
+	if r.defaultCore != nil {
+		clone.defaultCore = r.defaultCore.With(fields)
+	}
+
+	return clone
+}
+
+// Check routes the entry: the core selected for it checks the entry itself,
+// so that cores filtering in Check, such as samplers, apply. When the route
+// depends on a field the entry may carry, the router is added instead and
+// the route is selected in Write, once the fields are known.
+// It implements the zapcore.Core interface.
+func (r *RouterCore) Check(entry zapcore.Entry, checkEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !r.Enabled(entry.Level) {
+		return checkEntry
+	}
+
+	core, ok := r.routeEntry(entry)
+	if !ok {
+		return checkEntry.AddCore(entry, r)
+	}
+
+	if core == nil {
+		return checkEntry
+	}
+
+	return core.Check(entry, checkEntry)
+}
+
+// Write sends the entry to the core selected for it, if that core's Check
+// accepts the entry.
+// It implements the zapcore.Core interface.
+func (r *RouterCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	core := r.route(entry, fields)
+	if core == nil {
+		return nil
+	}
+
+	checked := core.Check(entry, nil)
+	if checked == nil {
+		return nil
+	}
+
+	// Writing the checked entry returns it to zap's pool. Write errors are
+	// only reported through its ErrorOutput.
+	var errs routedWriteErrors
+
+	checked.ErrorOutput = &errs
+	checked.Write(fields...)
+
+	return errs.err
+}
+
+// Sync flushes every routed core.
+// It implements the zapcore.Core interface.
+func (r *RouterCore) Sync() error {
+	var errs []error
+
+	if r.defaultCore != nil {
+		errs = append(errs, r.defaultCore.Sync())
+	}
+
+	for i := range r.routes {
+		errs = append(errs, r.routes[i].Core.Sync())
+	}
+
+	return errors.Join(errs...)
+}
+
+// routeEntry returns the core of the first route matching the entry, or the
+// default core, when the logger name, level and field values added via With
+// decide it. It returns false when a route matching on a field the entry may
+// carry comes first.
+func (r *RouterCore) routeEntry(entry zapcore.Entry) (zapcore.Core, bool) {
+	for i := range r.routes {
+		route := &r.routes[i]
+		if !route.matchesEntry(entry) {
+			continue
+		}
+
+		if !route.matches(entry, r.values) {
+			return nil, false
+		}
+
+		return route.Core, true
+	}
+
+	return r.defaultCore, true
+}
+
+// route returns the core of the first route matching the entry, or the
+// default core.
+func (r *RouterCore) route(entry zapcore.Entry, fields []zapcore.Field) zapcore.Core {
+	var values map[string]interface{}
+
+	for i := range r.routes {
+		route := &r.routes[i]
+		if route.FieldKey != "" && values == nil {
+			values = encodeFields(fields, nil).values
+		}
+
+		if route.matches(entry, values, r.values) {
+			return route.Core
+		}
+	}
+
+	return r.defaultCore
+}
+
+// loggerNameHasPrefix reports whether the dotted logger name equals prefix
+// or is nested under it.
+func loggerNameHasPrefix(name, prefix string) bool {
+	return name == prefix || strings.HasPrefix(name, prefix+".")
+}
+
+// routedWriteErrors collects the write errors a CheckedEntry reports to its
+// ErrorOutput as "<time> write error: <error>" lines.
+type routedWriteErrors struct {
+	err error
+}
+
+func (w *routedWriteErrors) Write(p []byte) (int, error) {
+	line := strings.TrimSpace(string(p))
+	if _, msg, ok := strings.Cut(line, " write error: "); ok {
+		line = msg
+	}
+
+	w.err = errors.Join(w.err, errors.New(line))
+
+	return len(p), nil
+}
+
+func (w *routedWriteErrors) Sync() error { return nil }
@@ -0,0 +1,166 @@
+package sentryzapcore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRouterCore(t *testing.T) {
+	defaultTransport := &transportMock{}
+	paymentsTransport := &transportMock{}
+	dbTransport := &transportMock{}
+	fatalTransport := &transportMock{}
+
+	newCore := func(transport *transportMock) *SentryCore {
+		return NewSentryCore(context.Background(),
+			WithHub(newTestHub(t, transport)),
+			WithMinLevel(zapcore.WarnLevel),
+		)
+	}
+
+	router := NewRouterCore(newCore(defaultTransport),
+		Route{Level: zapcore.DPanicLevel, Core: newCore(fatalTransport)},
+		Route{FieldKey: "team", FieldValue: "payments", Core: newCore(paymentsTransport)},
+		Route{LoggerPrefix: "db", Core: newCore(dbTransport)},
+	)
+
+	logger := zaptest.NewLogger(t, zaptest.WrapOptions(WithRouterOption(router), zap.Development()))
+
+	send := func(l *zap.Logger, fields ...zap.Field) string {
+		message := gofakeit.UUID()
+		l.Error(message, fields...)
+		require.NoError(t, l.Sync())
+
+		return message
+	}
+
+	assertRouted := func(message string, want *transportMock) {
+		t.Helper()
+
+		for _, transport := range []*transportMock{defaultTransport, paymentsTransport, dbTransport, fatalTransport} {
+			_, found := findLog(transport.Events(), message)
+			require.Equal(t, transport == want, found)
+		}
+	}
+
+	assertRouted(send(logger), defaultTransport)
+	assertRouted(send(logger, zap.String("team", "payments")), paymentsTransport)
+	assertRouted(send(logger.With(zap.String("team", "payments"))), paymentsTransport)
+	assertRouted(send(logger.Named("db")), dbTransport)
+	assertRouted(send(logger.Named("db").Named("pool")), dbTransport)
+	assertRouted(send(logger.Named("dbx")), defaultTransport)
+	assertRouted(send(logger.Named("db"), zap.String("team", "payments")), paymentsTransport)
+
+	message := gofakeit.UUID()
+	require.Panics(t, func() { logger.DPanic(message) })
+	require.NoError(t, logger.Sync())
+	assertRouted(message, fatalTransport)
+
+	// Entries below every core's level never reach the router.
+	message = gofakeit.UUID()
+	logger.Info(message)
+	require.NoError(t, logger.Sync())
+	assertRouted(message, nil)
+}
+
+func TestRouterCoreWithoutDefault(t *testing.T) {
+	transport := &transportMock{}
+	router := NewRouterCore(nil, Route{LoggerPrefix: "db", Core: NewSentryCore(context.Background(), WithHub(newTestHub(t, transport)))})
+	logger := zap.New(router)
+
+	message := gofakeit.Sentence()
+	logger.Error(message)
+	require.NoError(t, logger.Sync())
+
+	_, found := findLog(transport.Events(), message)
+	require.False(t, found)
+	require.False(t, router.Enabled(zapcore.InfoLevel))
+}
+
+func TestRouterCoreRoutedLevels(t *testing.T) {
+	observed, logs := observer.New(zapcore.WarnLevel)
+
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	levels, err := ParseLoggerLevels("db=error")
+	require.NoError(t, err)
+
+	router := NewRouterCore(observed, Route{LoggerPrefix: "db", Core: NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithMinLevel(zapcore.InfoLevel),
+		WithLoggerLevels(levels),
+	)})
+
+	require.NoError(t, router.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "info"}, nil))
+	require.NoError(t, router.Write(zapcore.Entry{Level: zapcore.WarnLevel, Message: "warn"}, nil))
+	require.Equal(t, []string{"warn"}, messages(logs))
+
+	message := gofakeit.Sentence()
+	require.NoError(t, router.Write(zapcore.Entry{Level: zapcore.WarnLevel, LoggerName: "db", Message: message}, nil))
+	hub.Flush(2 * time.Second)
+
+	_, found := findLog(transport.Events(), message)
+	require.False(t, found)
+}
+
+// failingCore is a core whose writes fail.
+type failingCore struct {
+	zapcore.LevelEnabler
+}
+
+func (c failingCore) With([]zapcore.Field) zapcore.Core { return c }
+
+func (c failingCore) Check(entry zapcore.Entry, checkEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return checkEntry.AddCore(entry, c)
+}
+
+func (failingCore) Write(zapcore.Entry, []zapcore.Field) error { return errors.New("boom") }
+
+func (failingCore) Sync() error { return nil }
+
+func TestRouterCoreSampler(t *testing.T) {
+	newSampled := func() (zapcore.Core, *observer.ObservedLogs) {
+		observed, logs := observer.New(zapcore.InfoLevel)
+		return zapcore.NewSamplerWithOptions(observed, time.Hour, 1, 0), logs
+	}
+
+	t.Run("default core", func(t *testing.T) {
+		sampled, logs := newSampled()
+		logger := zap.New(NewRouterCore(sampled))
+
+		for range 5 {
+			logger.Error("boom")
+		}
+
+		require.Equal(t, 1, logs.Len())
+	})
+
+	t.Run("field route", func(t *testing.T) {
+		sampled, logs := newSampled()
+		observed, others := observer.New(zapcore.InfoLevel)
+		logger := zap.New(NewRouterCore(observed, Route{FieldKey: "team", FieldValue: "payments", Core: sampled}))
+
+		for range 5 {
+			logger.Error("boom", zap.String("team", "payments"))
+		}
+
+		logger.Error("other")
+
+		require.Equal(t, 1, logs.Len())
+		require.Equal(t, []string{"other"}, messages(others))
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		router := NewRouterCore(failingCore{zapcore.InfoLevel})
+		require.EqualError(t, router.Write(zapcore.Entry{Level: zapcore.ErrorLevel}, nil), "boom")
+	})
+}
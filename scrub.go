@@ -0,0 +1,403 @@
+package sentryzapcore
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// errMissingHMACKey is returned by NewScrubber when RedactHash is selected
+// without a key.
+var errMissingHMACKey = errors.New("sentryzapcore: RedactHash requires WithHMACKey")
+
+// redactedValue replaces masked values.
+const redactedValue = "[REDACTED]"
+
+// Redaction is the policy a Scrubber applies to sensitive data.
+type Redaction int
+
+const (
+	// RedactMask replaces sensitive data with "[REDACTED]".
+	RedactMask Redaction = iota
+	// RedactDrop removes sensitive attributes altogether. Secrets found in
+	// the message or stack trace, which cannot be dropped, are masked.
+	RedactDrop
+	// RedactHash replaces sensitive data with the hex-encoded HMAC-SHA256 of
+	// the data under the key set with WithHMACKey, so that equal values can
+	// still be correlated.
+	RedactHash
+)
+
+// Common secret patterns for use with RedactValues.
+var (
+	// BearerTokenPattern matches HTTP bearer tokens.
+	BearerTokenPattern = regexp.MustCompile(`(?i)bearer\s+[a-z0-9\-._~+/]+=*`)
+	// CardNumberPattern matches 13 to 19 digit payment card numbers,
+	// optionally grouped by spaces or dashes.
+	CardNumberPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	// EmailPattern matches email addresses.
+	EmailPattern = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+)
+
+// ScrubberOption is a functional option for configuring a Scrubber.
+type ScrubberOption func(*Scrubber)
+
+// Scrubber redacts sensitive attributes, messages and stack traces before
+// they are sent to Sentry. Maps and structs logged with zap.Any are turned
+// into nested fields, through their JSON encoding, so that the key rules
+// apply to their keys; the value patterns apply to the string every value is
+// sent as. It is safe for concurrent use.
+type Scrubber struct {
+	denyKeys  []*regexp.Regexp
+	allowKeys []*regexp.Regexp
+	values    []*regexp.Regexp
+	redaction Redaction
+	hmacKey   []byte
+}
+
+// NewScrubber creates a Scrubber with the provided options. By default it
+// masks matches; it returns an error if RedactHash is chosen without a key.
+func NewScrubber(options ...ScrubberOption) (*Scrubber, error) {
+	s := &Scrubber{}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	if s.redaction == RedactHash && len(s.hmacKey) == 0 {
+		return nil, errMissingHMACKey
+	}
+
+	return s, nil
+}
+
+// DenyKeys redacts attributes whose key, or last key segment for nested
+// fields, matches any of the case-insensitive glob patterns ("*" matches any
+// run of characters, "?" a single one), for example "password" or "*token*".
+func DenyKeys(globs ...string) ScrubberOption {
+	return DenyKeyPatterns(globPatterns(globs)...)
+}
+
+// DenyKeyPatterns redacts attributes whose key, or last key segment for
+// nested fields, matches any of the regular expressions.
+func DenyKeyPatterns(patterns ...*regexp.Regexp) ScrubberOption {
+	return func(s *Scrubber) {
+		s.denyKeys = append(s.denyKeys, patterns...)
+	}
+}
+
+// AllowKeys redacts every attribute whose key, or last key segment for nested
+// fields, matches none of the allow-listed glob patterns. See DenyKeys for
+// the glob syntax. Deny-listed keys are redacted even when allowed.
+func AllowKeys(globs ...string) ScrubberOption {
+	return AllowKeyPatterns(globPatterns(globs)...)
+}
+
+// AllowKeyPatterns is like AllowKeys with regular expressions.
+func AllowKeyPatterns(patterns ...*regexp.Regexp) ScrubberOption {
+	return func(s *Scrubber) {
+		s.allowKeys = append(s.allowKeys, patterns...)
+	}
+}
+
+// RedactValues redacts the parts of string values, messages and stack traces
+// matching any of the regular expressions, such as BearerTokenPattern,
+// CardNumberPattern or EmailPattern. Values sent as strings, such as []byte
+// and fmt.Stringer values, are matched in that form. With RedactDrop, an
+// attribute containing a match is dropped.
+func RedactValues(patterns ...*regexp.Regexp) ScrubberOption {
+	return func(s *Scrubber) {
+		s.values = append(s.values, patterns...)
+	}
+}
+
+// WithRedaction sets the redaction policy. It defaults to RedactMask.
+func WithRedaction(redaction Redaction) ScrubberOption {
+	return func(s *Scrubber) {
+		s.redaction = redaction
+	}
+}
+
+// WithHMACKey sets the key used by RedactHash.
+func WithHMACKey(key []byte) ScrubberOption {
+	return func(s *Scrubber) {
+		s.hmacKey = append([]byte(nil), key...)
+	}
+}
+
+// normalizeValues replaces, in place, the maps and structs of reflected
+// field values (zap.Any) with their JSON encoding decoded to nested
+// map[string]interface{} values, so that they are flattened like zap.Object
+// fields and the key rules apply to their keys.
+func (s *Scrubber) normalizeValues(values map[string]interface{}) {
+	if s == nil {
+		return
+	}
+
+	for k, v := range values {
+		values[k] = normalizeValue(v)
+	}
+}
+
+// normalizeValue returns value with the maps and structs it holds decoded
+// from their JSON encoding. Values applyValue formats itself, such as
+// time.Time, errors and fmt.Stringer values, are kept, as are values that
+// cannot be encoded.
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, nested := range v {
+			v[k] = normalizeValue(nested)
+		}
+
+		return v
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeValue(elem)
+		}
+
+		return v
+	case time.Time, error, fmt.Stringer:
+		return value
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Map && rv.Kind() != reflect.Struct {
+		return value
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		return value
+	}
+
+	return jsonNumbers(decoded)
+}
+
+// jsonNumbers replaces the json.Number values of a decoded JSON value with
+// int64 or float64 values.
+func jsonNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, nested := range v {
+			v[k] = jsonNumbers(nested)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = jsonNumbers(elem)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+
+		return v.String()
+	}
+
+	return value
+}
+
+// scrubValues redacts the flattened field values in place. separator is the
+// one used to flatten nested keys.
+func (s *Scrubber) scrubValues(values map[string]interface{}, separator string) {
+	if s == nil {
+		return
+	}
+
+	for k, v := range values {
+		if scrubbed, keep := s.scrubAttribute(k, v, separator); keep {
+			values[k] = scrubbed
+		} else {
+			delete(values, k)
+		}
+	}
+}
+
+// scrubAttribute returns the redacted value of an attribute, or false when
+// the policy drops it.
+func (s *Scrubber) scrubAttribute(key string, value interface{}, separator string) (interface{}, bool) {
+	if s.sensitiveKey(key, separator) {
+		if s.redaction == RedactDrop {
+			return nil, false
+		}
+
+		return s.replace(fmt.Sprint(value)), true
+	}
+
+	scrubbed, matched := s.scrubValue(value)
+	if !matched {
+		return value, true
+	}
+
+	return scrubbed, s.redaction != RedactDrop
+}
+
+// scrubMap returns a redacted copy of a nested map, such as a Sentry
+// context. The keys of its attributes are prefix and the keys leading to
+// them, joined by separator.
+func (s *Scrubber) scrubMap(values map[string]interface{}, prefix, separator string) map[string]interface{} {
+	scrubbed := make(map[string]interface{}, len(values))
+
+	for k, v := range values {
+		key := prefix + separator + k
+
+		if nested, ok := v.(map[string]interface{}); ok && !s.sensitiveKey(key, separator) {
+			scrubbed[k] = s.scrubMap(nested, key, separator)
+			continue
+		}
+
+		if v, keep := s.scrubAttribute(key, v, separator); keep {
+			scrubbed[k] = v
+		}
+	}
+
+	return scrubbed
+}
+
+// scrubValue redacts secrets in string values, slice elements and the
+// string form of other values that are sent as strings. It reports whether
+// any secret was found.
+func (s *Scrubber) scrubValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		scrubbed := s.scrubString(v)
+		return scrubbed, scrubbed != v
+	case []interface{}:
+		var matched bool
+
+		scrubbed := make([]interface{}, len(v))
+		for i, elem := range v {
+			var elemMatched bool
+
+			scrubbed[i], elemMatched = s.scrubValue(elem)
+			matched = matched || elemMatched
+		}
+
+		return scrubbed, matched
+	default:
+		str, ok := stringForm(value)
+		if !ok {
+			return value, false
+		}
+
+		scrubbed := s.scrubString(str)
+
+		return scrubbed, scrubbed != str
+	}
+}
+
+// stringForm returns the string applyValue sends a value as, if it sends
+// it as a string.
+func stringForm(value interface{}) (string, bool) {
+	sink := &stringSink{}
+	applyValue(value, sink)
+
+	return sink.value, sink.set
+}
+
+// stringSink is a valueSink that only records string values.
+type stringSink struct {
+	value string
+	set   bool
+}
+
+func (sink *stringSink) SetString(value string)    { sink.value, sink.set = value, true }
+func (sink *stringSink) SetBool(bool)              {}
+func (sink *stringSink) SetInt(int)                {}
+func (sink *stringSink) SetInt64(int64)            {}
+func (sink *stringSink) SetFloat64(float64)        {}
+func (sink *stringSink) SetStringSlice([]string)   {}
+func (sink *stringSink) SetInt64Slice([]int64)     {}
+func (sink *stringSink) SetFloat64Slice([]float64) {}
+func (sink *stringSink) SetBoolSlice([]bool)       {}
+
+// scrubString redacts the secrets found in a message, stack trace or string
+// value. Under RedactDrop, secrets are masked.
+func (s *Scrubber) scrubString(value string) string {
+	if s == nil {
+		return value
+	}
+
+	for _, pattern := range s.values {
+		value = pattern.ReplaceAllStringFunc(value, s.replace)
+	}
+
+	return value
+}
+
+// replace returns the replacement for sensitive data under the policy.
+func (s *Scrubber) replace(value string) string {
+	if s.redaction != RedactHash {
+		return redactedValue
+	}
+
+	mac := hmac.New(sha256.New, s.hmacKey)
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sensitiveKey reports whether the attribute key must be redacted.
+func (s *Scrubber) sensitiveKey(key, separator string) bool {
+	last := key
+	if separator != "" {
+		if i := strings.LastIndex(key, separator); i >= 0 {
+			last = key[i+len(separator):]
+		}
+	}
+
+	if matchKey(s.denyKeys, key, last) {
+		return true
+	}
+
+	return len(s.allowKeys) > 0 && !matchKey(s.allowKeys, key, last)
+}
+
+func matchKey(patterns []*regexp.Regexp, key, last string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(key) || pattern.MatchString(last) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// globPatterns compiles case-insensitive glob patterns to anchored regular
+// expressions.
+func globPatterns(globs []string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(globs))
+
+	for _, glob := range globs {
+		expr := regexp.QuoteMeta(glob)
+		expr = strings.ReplaceAll(expr, `\*`, `.*`)
+		expr = strings.ReplaceAll(expr, `\?`, `.`)
+		patterns = append(patterns, regexp.MustCompile(`(?i)^`+expr+`$`))
+	}
+
+	return patterns
+}
@@ -0,0 +1,234 @@
+package sentryzapcore
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestScrubber(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	newLogger := func(t *testing.T, options ...ScrubberOption) *zap.Logger {
+		t.Helper()
+
+		scrubber, err := NewScrubber(options...)
+		require.NoError(t, err)
+
+		return zap.New(NewSentryCore(context.Background(),
+			WithHub(hub),
+			WithScrubber(scrubber),
+			WithStackTrace(),
+			WithEventLevel(zapcore.ErrorLevel),
+		))
+	}
+
+	t.Run("mask", func(t *testing.T) {
+		logger := newLogger(t,
+			DenyKeys("password", "*token*"),
+			RedactValues(BearerTokenPattern, CardNumberPattern, EmailPattern),
+		).With(zap.String("api_token", "secret"))
+
+		message := "login failed for " + gofakeit.Email()
+		logger.Error(message,
+			zap.String("password", "hunter2"),
+			zap.Object("request", headers{"password": "hunter2", "authorization": "Bearer abc.def"}),
+			zap.String("card", "4111 1111 1111 1111"),
+			zap.Strings("recipients", []string{"ops@example.com", "team"}),
+			zap.String("user", "alice"),
+			zap.Error(errors.New("cannot reach ops@example.com")),
+		)
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), "login failed for [REDACTED]")
+		require.True(t, found)
+		require.Equal(t, redactedValue, logEntry.Attributes["password"].String())
+		require.Equal(t, redactedValue, logEntry.Attributes["api_token"].String())
+		require.Equal(t, redactedValue, logEntry.Attributes["request.password"].String())
+		require.Equal(t, redactedValue, logEntry.Attributes["request.authorization"].String())
+		require.Equal(t, redactedValue, logEntry.Attributes["card"].String())
+		require.Equal(t, []string{redactedValue, "team"}, logEntry.Attributes["recipients"].AsStringSlice())
+		require.Equal(t, "alice", logEntry.Attributes["user"].String())
+		require.Equal(t, "cannot reach [REDACTED]", logEntry.Attributes["error"].String())
+
+		event, found := findEvent(transport.Events(), "login failed for [REDACTED]")
+		require.True(t, found)
+		require.Equal(t, "cannot reach [REDACTED]", event.Exception[0].Value)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		logger := newLogger(t, DenyKeys("password"), RedactValues(EmailPattern), WithRedaction(RedactDrop))
+
+		message := gofakeit.Sentence()
+		logger.Error(message, zap.String("password", "hunter2"), zap.String("contact", "a@example.com"), zap.Int("id", 1))
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.NotContains(t, logEntry.Attributes, "password")
+		require.NotContains(t, logEntry.Attributes, "contact")
+		require.Equal(t, int64(1), logEntry.Attributes["id"].AsInt64())
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		logger := newLogger(t, DenyKeys("password"), WithRedaction(RedactHash), WithHMACKey([]byte("key")))
+
+		message := gofakeit.Sentence()
+		logger.Error(message, zap.String("password", "hunter2"))
+		logger.Error(message, zap.String("password", "hunter2"))
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		hashed := logEntry.Attributes["password"].String()
+		require.Len(t, hashed, 64)
+		require.NotContains(t, hashed, "hunter2")
+	})
+
+	t.Run("allow list", func(t *testing.T) {
+		logger := newLogger(t, AllowKeys("id", "request.*"), DenyKeyPatterns(regexp.MustCompile(`secret`)))
+
+		message := gofakeit.Sentence()
+		logger.Error(message,
+			zap.Int("id", 7),
+			zap.String("name", "alice"),
+			zap.Object("request", headers{"host": "example.com", "secret": "x"}),
+		)
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, int64(7), logEntry.Attributes["id"].AsInt64())
+		require.Equal(t, redactedValue, logEntry.Attributes["name"].String())
+		require.Equal(t, "example.com", logEntry.Attributes["request.host"].String())
+		require.Equal(t, redactedValue, logEntry.Attributes["request.secret"].String())
+	})
+
+	t.Run("sentry fields", func(t *testing.T) {
+		logger := newLogger(t, DenyKeys("password", "*email*"), RedactValues(EmailPattern)).
+			With(Contexts("auth", map[string]interface{}{
+				"password": "hunter2",
+				"method":   "basic",
+				"client":   map[string]interface{}{"contact": "ops@example.com", "name": "cli"},
+			}))
+
+		message := gofakeit.Sentence()
+		logger.Error(message,
+			User("1", "alice@example.com", "alice"),
+			Tag("password", "hunter2"),
+			Tag("owner", "bob@example.com"),
+			Tag("region", "eu"),
+		)
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, redactedValue, logEntry.Attributes["password"].String())
+		require.Equal(t, redactedValue, logEntry.Attributes["owner"].String())
+		require.Equal(t, "eu", logEntry.Attributes["region"].String())
+		require.Equal(t, "1", logEntry.Attributes["user.id"].String())
+		require.Equal(t, redactedValue, logEntry.Attributes["user.email"].String())
+		require.Equal(t, "alice", logEntry.Attributes["user.name"].String())
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, sentry.User{ID: "1", Email: redactedValue, Username: "alice"}, event.User)
+		require.Equal(t, redactedValue, event.Tags["password"])
+		require.Equal(t, redactedValue, event.Tags["owner"])
+		require.Equal(t, "eu", event.Tags["region"])
+		require.Equal(t, map[string]interface{}{
+			"password": redactedValue,
+			"method":   "basic",
+			"client":   map[string]interface{}{"contact": redactedValue, "name": "cli"},
+		}, event.Contexts["auth"])
+
+		dropping := newLogger(t, DenyKeys("password", "*email*"), WithRedaction(RedactDrop))
+
+		message = gofakeit.Sentence()
+		dropping.Error(message, User("1", "alice@example.com", ""), Tag("password", "hunter2"), Tag("region", "eu"))
+
+		event, found = findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, sentry.User{ID: "1"}, event.User)
+		require.Equal(t, map[string]string{"region": "eu", "zap.level": "error"}, event.Tags)
+	})
+
+	t.Run("stack trace", func(t *testing.T) {
+		scrubber, err := NewScrubber(RedactValues(regexp.MustCompile(`TestScrubber`)))
+		require.NoError(t, err)
+
+		logger := zap.New(NewSentryCore(context.Background(), WithHub(hub), WithScrubber(scrubber), WithStackTrace()))
+
+		message := gofakeit.Sentence()
+		logger.Error(message)
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.NotContains(t, logEntry.Attributes["stacktrace"].String(), "TestScrubber")
+		require.Contains(t, logEntry.Attributes["stacktrace"].String(), redactedValue)
+	})
+}
+
+func TestScrubberReflectedValues(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	scrubber, err := NewScrubber(DenyKeys("password", "authorization"), RedactValues(BearerTokenPattern))
+	require.NoError(t, err)
+
+	logger := zap.New(NewSentryCore(context.Background(), WithHub(hub), WithScrubber(scrubber)))
+
+	type credentials struct {
+		User     string
+		Password string
+		Attempts int
+	}
+
+	t.Run("map", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		logger.Error(message, zap.Any("headers", map[string]string{"Authorization": "Bearer abc.def", "Host": "example.com"}))
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, redactedValue, logEntry.Attributes["headers.Authorization"].String())
+		require.Equal(t, "example.com", logEntry.Attributes["headers.Host"].String())
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		logger.Error(message, zap.Any("creds", &credentials{User: "alice", Password: "hunter2", Attempts: 3}))
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, redactedValue, logEntry.Attributes["creds.Password"].String())
+		require.Equal(t, "alice", logEntry.Attributes["creds.User"].String())
+		require.Equal(t, int64(3), logEntry.Attributes["creds.Attempts"].AsInt64())
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		logger.Error(message, zap.Binary("raw", []byte("token: Bearer xyz")))
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, "token: [REDACTED]", logEntry.Attributes["raw"].String())
+	})
+}
+
+func TestNewScrubberRequiresHMACKey(t *testing.T) {
+	_, err := NewScrubber(WithRedaction(RedactHash))
+	require.ErrorIs(t, err, errMissingHMACKey)
+}
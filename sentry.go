@@ -5,6 +5,7 @@ package sentryzapcore
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime/debug"
 	"time"
 
@@ -13,9 +14,30 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-// errFlushTimeout is returned by Sync when Sentry does not finish delivering
-// buffered events within flushTimeout.
-var errFlushTimeout = errors.New("sentryzapcore: flush timed out")
+// errNoClient ends a flush when the hub has no client to flush.
+var errNoClient = errors.New("sentryzapcore: no Sentry client")
+
+// FlushError is returned by Sync and SyncContext when entries may not have
+// been delivered to Sentry.
+type FlushError struct {
+	// Undelivered is the number of entries queued with WithAsync that were
+	// not written when the flush gave up. Entries buffered by the Sentry SDK
+	// are not counted, as the SDK does not report them.
+	Undelivered int
+	// Err is the reason the flush gave up, usually
+	// context.DeadlineExceeded.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *FlushError) Error() string {
+	return fmt.Sprintf("sentryzapcore: flush incomplete, %d queued entries undelivered: %v", e.Undelivered, e.Err)
+}
+
+// Unwrap returns the reason the flush gave up.
+func (e *FlushError) Unwrap() error {
+	return e.Err
+}
 
 // Ensure SentryCore implements zapcore.Core interface.
 var _ zapcore.Core = (*SentryCore)(nil)
@@ -24,11 +46,37 @@ var _ zapcore.Core = (*SentryCore)(nil)
 // It can be used alongside other cores to send logs to multiple destinations.
 type SentryCore struct {
 	zapcore.LevelEnabler // determines which log levels are enabled
+	ctx                  context.Context
+	hub                  *sentry.Hub // hub bound with WithHub/WithClient; nil resolves it per entry
 	logger               sentry.Logger
 	attributes           []attribute.Builder
-	stackTrace           bool // include stack traces for error-level logs
+	stackTrace           bool                 // include stack traces for error-level logs
+	eventLevel           zapcore.LevelEnabler // levels captured as Sentry events; nil disables
+	breadcrumbLevel      zapcore.LevelEnabler // levels recorded as breadcrumbs; nil disables
+	maxBreadcrumbs       int                  // breadcrumb ring-buffer cap; 0 uses the client limit
+	fieldSeparator       string               // joins keys of nested fields
+	maxFieldDepth        int                  // nesting levels of nested fields that are flattened
+	converters           []ValueConverter     // run ahead of the built-in value conversions
+	scrubber             *Scrubber            // redacts sensitive data; nil disables
+	fields               sentryFields         // Sentry metadata added via With
+	rateLimiter          *rateLimiter         // limits entries per key; nil disables
+	deduplicator         *deduplicator        // merges identical entries; nil disables
+	dedupWith            *dedupWith           // fields added via With, for deduplication keys
+	async                *asyncWriter         // writes entries on worker goroutines; nil disables
+	flushTimeout         time.Duration        // how long Sync waits for delivery
+	panicFlushTimeout    time.Duration        // how long DPanic, Panic and Fatal entries wait for delivery
+	levelMapper          LevelMapper          // maps zap levels to Sentry severities; nil uses the defaults
+	loggerLevels         *LoggerLevels        // minimum levels per logger name; nil disables
+	beforeEmit           []BeforeEmitFunc     // may rewrite or veto entries
+	inAppPrefixes        []string             // module prefixes of in_app stack frames
 }
 
+// BeforeEmitFunc is called by SentryCore.Write with the entry and its
+// encoded field values before anything is sent to Sentry. It returns the
+// entry and attributes to send, and false to drop the entry. The attrs map
+// may be modified in place.
+type BeforeEmitFunc func(entry zapcore.Entry, attrs map[string]interface{}) (zapcore.Entry, map[string]interface{}, bool)
+
 // NewSentryCore creates a new SentryCore with the provided options.
 // By default, it only sends logs at Error level or above to Sentry.
 func NewSentryCore(ctx context.Context, options ...SentryCoreOptions) *SentryCore {
@@ -36,78 +84,265 @@ func NewSentryCore(ctx context.Context, options ...SentryCoreOptions) *SentryCor
 		ctx = context.Background()
 	}
 
-	logger := sentry.NewLogger(ctx)
-
 	s := &SentryCore{
-		LevelEnabler: zapcore.ErrorLevel,
-		logger:       logger,
+		LevelEnabler:      zapcore.ErrorLevel,
+		fieldSeparator:    defaultFieldSeparator,
+		maxFieldDepth:     defaultMaxFieldDepth,
+		flushTimeout:      defaultFlushTimeout,
+		panicFlushTimeout: defaultPanicFlushTimeout,
 	}
 
 	for _, opt := range options {
 		opt(s)
 	}
 
+	s.ctx = s.bindContext(ctx)
+	s.logger = sentry.NewLogger(s.ctx)
+
 	if len(s.attributes) > 0 {
 		s.logger.SetAttributes(s.attributes...)
 	}
 
+	if s.async != nil {
+		s.async.start()
+	}
+
 	return s
 }
 
 // With adds structured context as additional attributes on the Core.
 // It implements the zapcore.Core interface.
 func (s *SentryCore) With(fields []zapcore.Field) zapcore.Core {
-	ctx := s.logger.GetCtx()
-	if ctx == nil {
-		ctx = context.Background()
-	}
+	ctx := s.ctx
 
 	attrs := append([]attribute.Builder(nil), s.attributes...)
 
-	fieldCtx, values := encodeFields(fields)
-	if fieldCtx != nil {
-		ctx = fieldCtx
+	encoded := s.encodeFields(fields)
+	if encoded.ctx != nil {
+		ctx = s.bindContext(encoded.ctx)
 	}
 
-	attrs = append(attrs, attributesFromValues(values)...)
+	attrs = append(attrs, attributesFromValues(encoded.values)...)
 
 	logger := sentry.NewLogger(ctx)
 	if len(attrs) > 0 {
 		logger.SetAttributes(attrs...)
 	}
 
-	return &SentryCore{
-		LevelEnabler: s.LevelEnabler,
-		logger:       logger,
-		attributes:   attrs,
-		stackTrace:   s.stackTrace,
+	clone := *s
+	clone.ctx = ctx
+	clone.logger = logger
+	clone.attributes = attrs
+	clone.fields = encoded.fields
+
+	if s.deduplicator != nil {
+		clone.dedupWith = s.dedupWith.with(encoded.values, encoded.fields)
 	}
+
+	return &clone
+}
+
+// Enabled reports whether the core handles entries at the given level,
+// either as structured logs, Sentry events or breadcrumbs.
+// It implements the zapcore.LevelEnabler interface.
+func (s *SentryCore) Enabled(level zapcore.Level) bool {
+	return s.LevelEnabler.Enabled(level) || s.eventEnabled(level) || s.breadcrumbEnabled(level) ||
+		s.loggerLevels.anyEnabled(level)
 }
 
 // Check determines whether the supplied Entry should be logged.
 // It implements the zapcore.Core interface.
 func (s *SentryCore) Check(entry zapcore.Entry, checkEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
-	if s.Enabled(entry.Level) {
+	if s.entryEnabled(entry) {
 		return checkEntry.AddCore(entry, s)
 	}
 
 	return checkEntry
 }
 
-// Write takes a log entry and sends it to Sentry as a structured log.
+// entryEnabled reports whether the core handles the entry, applying the
+// level set for its logger with WithLoggerLevels, if any.
+func (s *SentryCore) entryEnabled(entry zapcore.Entry) bool {
+	if level, ok := s.loggerLevels.Level(entry.LoggerName); ok {
+		return level.Enabled(entry.Level)
+	}
+
+	return s.Enabled(entry.Level)
+}
+
+// Write takes a log entry and sends it to Sentry as a structured log and,
+// when enabled with WithEventLevel, as a Sentry event. Entries that are
+// neither are recorded as breadcrumbs when enabled with WithBreadcrumbs.
+// Entries over the rate limit set with WithRateLimit are suppressed, except
+// DPanic, Panic and Fatal ones, and hooks registered with WithBeforeEmit run
+// first and may drop the entry.
+// With WithDeduplication, the entry is held to be merged with identical ones.
+// With WithAsync, the entry is queued and the rest of the work happens on a
+// worker goroutine; DPanic, Panic and Fatal entries are written right away,
+// after the queue.
 // It implements the zapcore.Core interface.
 func (s *SentryCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
-	logEntry := logEntryForLevel(s.logger, entry.Level)
+	if entry.Level <= zapcore.ErrorLevel && s.sent(entry) {
+		allowed, summaries := s.rateLimiter.allow(s, entry)
+		emitRateLimitSummaries(summaries)
+
+		if !allowed {
+			return nil
+		}
+	}
+
+	if entry.Stack == "" && s.stackTraceEnabled(entry.Level) {
+		entry.Stack = string(debug.Stack())
+	}
+
+	if entry.Level > zapcore.ErrorLevel {
+		return s.writeAndFlush(entry, fields)
+	}
+
+	if s.async != nil {
+		queued := asyncEntry{core: s, entry: entry, fields: append([]zapcore.Field(nil), fields...)}
+		if s.async.enqueue(queued) {
+			return nil
+		}
+	}
 
-	ctx, values := encodeFields(fields)
-	if ctx != nil {
-		logEntry = logEntry.WithCtx(ctx)
+	return s.write(entry, fields)
+}
+
+// writeAndFlush writes a DPanic, Panic or Fatal entry after the queued
+// entries and flushes Sentry before returning, as zap may panic or exit the
+// process right after: the hub of the core and, if different, the hub the
+// entry was sent through. It blocks up to panicFlushTimeout in total.
+func (s *SentryCore) writeAndFlush(entry zapcore.Entry, fields []zapcore.Field) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.panicFlushTimeout)
+	defer cancel()
+
+	s.async.drain(ctx)
+
+	if err := s.write(entry, fields); err != nil {
+		return err
+	}
+
+	errs := []error{s.SyncContext(ctx)}
+
+	// An unbound core sends the entry through the hub of its context field.
+	if hub := s.hubFor(contextFromFields(fields)); hub != s.hubFor(nil) {
+		errs = append(errs, flushHub(ctx, hub, 0))
+	}
+
+	var result error
+
+	for _, err := range errs {
+		if !errors.Is(err, errNoClient) {
+			result = errors.Join(result, err)
+		}
+	}
+
+	return result
+}
+
+// write encodes the entry fields and sends the entry to Sentry, unless a
+// hook drops it or it is held for deduplication.
+func (s *SentryCore) write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoded := s.encodeFields(fields)
+
+	entry.Message = s.scrubber.scrubString(entry.Message)
+	entry.Stack = s.scrubber.scrubString(entry.Stack)
+
+	var send bool
+
+	entry, encoded.values, send = s.runBeforeEmit(entry, encoded.values)
+	if !send {
+		return nil
+	}
+
+	if !s.deduplicator.hold(s, entry, encoded) {
+		s.emit(entry, encoded)
+	}
+
+	return nil
+}
+
+// runBeforeEmit runs the hooks registered with WithBeforeEmit. It returns
+// false when one of them drops the entry.
+func (s *SentryCore) runBeforeEmit(entry zapcore.Entry, values map[string]interface{}) (zapcore.Entry, map[string]interface{}, bool) {
+	for _, hook := range s.beforeEmit {
+		var send bool
+
+		entry, values, send = hook(entry, values)
+		if !send {
+			return entry, values, false
+		}
+	}
+
+	return entry, values, true
+}
+
+// logTargets reports whether the entry is sent as a structured log, and
+// whether the level set for its logger with WithLoggerLevels, if any, allows
+// it at all.
+func (s *SentryCore) logTargets(entry zapcore.Entry) (logged, allowed bool) {
+	if level, ok := s.loggerLevels.Level(entry.LoggerName); ok {
+		logged = level.Enabled(entry.Level)
+		return logged, logged
 	}
 
-	for k, v := range values {
+	return s.LevelEnabler.Enabled(entry.Level), true
+}
+
+// sent reports whether the entry is sent as a structured log or captured as
+// an event, rather than only kept as a breadcrumb.
+func (s *SentryCore) sent(entry zapcore.Entry) bool {
+	logged, allowed := s.logTargets(entry)
+	return logged || allowed && s.eventEnabled(entry.Level)
+}
+
+// emit sends the entry to Sentry as a structured log, an event or a
+// breadcrumb, as enabled for its level and logger.
+func (s *SentryCore) emit(entry zapcore.Entry, encoded encodedFields) {
+	logged, allowed := s.logTargets(entry)
+
+	if logged {
+		s.emitLog(entry, encoded)
+	}
+
+	captured := allowed && s.eventEnabled(entry.Level)
+	if captured {
+		s.captureEvent(entry, encoded)
+	}
+
+	if allowed && !logged && !captured && s.breadcrumbEnabled(entry.Level) {
+		s.addBreadcrumb(entry, encoded)
+	}
+}
+
+// emitLog sends the entry to Sentry as a structured log.
+func (s *SentryCore) emitLog(entry zapcore.Entry, encoded encodedFields) {
+	var logEntry sentry.LogEntry
+
+	switch {
+	case encoded.fields.level != "":
+		logEntry = logEntryForSentryLevel(s.logger, encoded.fields.level)
+	case s.levelMapper != nil:
+		logEntry = logEntryForLogLevel(s.logger, s.levelMapper(entry.Level))
+	default:
+		logEntry = logEntryForLogLevel(s.logger, logLevelForLevel(entry.Level))
+	}
+
+	if encoded.ctx != nil {
+		logEntry = logEntry.WithCtx(s.bindContext(encoded.ctx))
+	}
+
+	for k, v := range encoded.values {
 		logEntry = applyValueToLogEntry(logEntry, k, v)
 	}
 
+	for _, attr := range encoded.fields.logAttributes() {
+		logEntry = applyValueToLogEntry(logEntry, attr.Key, attr.Value.AsInterface())
+	}
+
+	logEntry = logEntry.String("zap.level", entry.Level.String())
+
 	if entry.LoggerName != "" {
 		logEntry = logEntry.String("logger", entry.LoggerName)
 	}
@@ -117,71 +352,206 @@ func (s *SentryCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
 		logEntry = logEntry.Int("caller.line", entry.Caller.Line)
 	}
 
-	if s.stackTrace && entry.Level >= zapcore.ErrorLevel {
-		stack := entry.Stack
-		if stack == "" {
-			stack = string(debug.Stack())
-		}
-
-		logEntry = logEntry.String("stacktrace", stack)
+	if s.stackTrace && entry.Level >= zapcore.ErrorLevel && entry.Stack != "" {
+		logEntry = logEntry.String("stacktrace", entry.Stack)
 	}
 
 	logEntry.Emit(entry.Message)
+}
 
-	return nil
+// stackTraceEnabled reports whether a stack trace is reported for entries at
+// the given level: error-level logs and captured events, with WithStackTrace.
+func (s *SentryCore) stackTraceEnabled(level zapcore.Level) bool {
+	return s.stackTrace && (level >= zapcore.ErrorLevel || s.eventEnabled(level))
 }
 
-// flushTimeout is the maximum time Sync waits for buffered Sentry events
-// to be delivered before returning.
-const flushTimeout = 2 * time.Second
+// defaultFlushTimeout is the default maximum time Sync waits for buffered
+// Sentry events to be delivered before returning.
+const defaultFlushTimeout = 2 * time.Second
 
-// Sync flushes any buffered log entries to Sentry, blocking up to
-// flushTimeout. It returns an error if the flush times out.
+// defaultPanicFlushTimeout is the default maximum time a DPanic, Panic or
+// Fatal entry waits to be delivered before zap panics or exits.
+const defaultPanicFlushTimeout = 2 * time.Second
+
+// Sync is SyncContext with the timeout set with WithFlushTimeout, 2 seconds
+// by default.
 // It implements the zapcore.Core interface.
-func (*SentryCore) Sync() error {
-	if !sentry.Flush(flushTimeout) {
-		return errFlushTimeout
+func (s *SentryCore) Sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.flushTimeout)
+	defer cancel()
+
+	return s.SyncContext(ctx)
+}
+
+// SyncContext writes the entries queued with WithAsync, emits the entries
+// held for deduplication, reports the entries suppressed by the rate limit so
+// far and flushes any buffered log entries to Sentry, blocking until ctx is
+// done. Only the client of the hub the core sends to is flushed: the one
+// bound with WithHub or WithClient, else the one of the core's context, else
+// the current hub.
+// It returns a *FlushError if the flush does not complete.
+func (s *SentryCore) SyncContext(ctx context.Context) error {
+	undelivered := s.async.drain(ctx)
+	s.deduplicator.flush()
+	emitRateLimitSummaries(s.rateLimiter.flush())
+
+	return flushHub(ctx, s.hubFor(nil), undelivered)
+}
+
+// flushHub flushes the client of hub, blocking until ctx is done. It returns
+// a *FlushError, counting undelivered queued entries, if the flush does not
+// complete.
+func flushHub(ctx context.Context, hub *sentry.Hub, undelivered int) error {
+	if hub.Client() == nil {
+		return &FlushError{Undelivered: undelivered, Err: errNoClient}
+	}
+
+	if !hub.FlushWithContext(ctx) || undelivered > 0 {
+		err := ctx.Err()
+		if err == nil {
+			err = context.DeadlineExceeded
+		}
+
+		return &FlushError{Undelivered: undelivered, Err: err}
 	}
 
 	return nil
 }
 
-// logEntryForLevel returns a sentry.LogEntry for the given zap log level.
-// Debug/Info/Warn map to their sentry counterparts;
-// Error, DPanic, Panic, and Fatal all map to Error (sentry logs do not
-// have separate panic/fatal channels).
-func logEntryForLevel(logger sentry.Logger, level zapcore.Level) sentry.LogEntry {
+// Close stops the worker goroutines started by WithAsync, once the queued
+// entries are written, and syncs the core. Later entries are written
+// synchronously. The cores derived via With share the workers, so closing any
+// of them closes all.
+func (s *SentryCore) Close() error {
+	s.async.close()
+
+	return s.Sync()
+}
+
+// AsyncStats reports the queue state and drop counters of a core created
+// with WithAsync. It returns zero stats otherwise.
+func (s *SentryCore) AsyncStats() AsyncStats {
+	return s.async.snapshot()
+}
+
+// logLevelForLevel returns the default Sentry log level of the given zap
+// log level, used without a LevelMapper and for the levels missing from
+// Config.LevelMapping. Debug/Info/Warn map to their sentry counterparts, and
+// custom levels below Debug to Debug, as for events;
+// Error, DPanic, Panic, Fatal and custom levels above them all map to Error
+// (sentry logs do not have separate panic/fatal channels).
+func logLevelForLevel(level zapcore.Level) sentry.LogLevel {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return sentry.LogLevelDebug
+	case level == zapcore.InfoLevel:
+		return sentry.LogLevelInfo
+	case level == zapcore.WarnLevel:
+		return sentry.LogLevelWarn
+	default:
+		return sentry.LogLevelError
+	}
+}
+
+// logEntryForSentryLevel returns a sentry.LogEntry for a level set with the
+// Level field. Fatal maps to LFatal, which does not exit the process.
+func logEntryForSentryLevel(logger sentry.Logger, level sentry.Level) sentry.LogEntry {
 	switch level {
-	case zapcore.DebugLevel:
+	case sentry.LevelDebug:
 		return logger.Debug()
-	case zapcore.InfoLevel:
+	case sentry.LevelInfo:
 		return logger.Info()
-	case zapcore.WarnLevel:
+	case sentry.LevelWarning:
 		return logger.Warn()
+	case sentry.LevelFatal:
+		return logger.LFatal()
 	default:
 		return logger.Error()
 	}
 }
 
-// encodeFields iterates zap fields, extracts a context.Context if present
-// (SkipType fields), and collects the rest into a flat map via a
-// zapcore.MapObjectEncoder.
-func encodeFields(fields []zapcore.Field) (context.Context, map[string]interface{}) {
-	var ctx context.Context
+// logEntryForLogLevel returns a sentry.LogEntry for a level returned by a
+// LevelMapper. Fatal maps to LFatal, which does not exit the process.
+func logEntryForLogLevel(logger sentry.Logger, level sentry.LogLevel) sentry.LogEntry {
+	switch level {
+	case sentry.LogLevelTrace:
+		return logger.Trace()
+	case sentry.LogLevelDebug:
+		return logger.Debug()
+	case sentry.LogLevelInfo:
+		return logger.Info()
+	case sentry.LogLevelWarn:
+		return logger.Warn()
+	case sentry.LogLevelFatal:
+		return logger.LFatal()
+	default:
+		return logger.Error()
+	}
+}
+
+// encodeFields encodes the fields, merges the Sentry metadata they carry
+// over the core's and scrubs it, applies the registered value converters,
+// flattens nested objects and namespaces into keys joined by the core's
+// field separator, describes the error chains and scrubs the result.
+func (s *SentryCore) encodeFields(fields []zapcore.Field) encodedFields {
+	encoded := encodeFields(fields, s.converters)
+	encoded.fields = s.fields.merge(encoded.fields).scrub(s.scrubber, s.fieldSeparator)
+	convertValues(encoded.values, s.converters)
+	s.scrubber.normalizeValues(encoded.values)
+	encoded.values = flattenValues(encoded.values, s.fieldSeparator, s.maxFieldDepth)
+	addErrorValues(encoded.values, encoded.errs, s.fieldSeparator, s.stackTrace)
+	s.scrubber.scrubValues(encoded.values, s.fieldSeparator)
+
+	return encoded
+}
+
+// encodedFields is the result of encodeFields.
+type encodedFields struct {
+	ctx    context.Context        // context carried by a SkipType field, if any
+	values map[string]interface{} // flattened field values
+	errs   []fieldError           // errors carried by zap.Error/zap.NamedError fields
+	fields sentryFields           // values of Tag, User, Level and the other field constructors
+}
+
+// encodeFields iterates zap fields, extracts a context.Context and the values
+// of the Sentry field constructors (SkipType fields), remembers the errors of ErrorType fields, and collects
+// the rest into a flat map via a zapcore.MapObjectEncoder. The values of
+// zap.Stringer and zap.Any fields are passed to the converters before zap
+// encodes them, so that converters see the original type; their results are
+// marked as convertedValue for convertValues to leave alone.
+func encodeFields(fields []zapcore.Field, converters []ValueConverter) encodedFields {
+	var encoded encodedFields
 
 	enc := zapcore.NewMapObjectEncoder()
 
 	for _, f := range fields {
 		if f.Type == zapcore.SkipType {
 			if v, ok := f.Interface.(context.Context); ok && v != nil {
-				ctx = v
+				encoded.ctx = v
+			} else {
+				encoded.fields.add(f)
 			}
 
 			continue
 		}
 
+		if f.Type == zapcore.ErrorType {
+			if err, ok := f.Interface.(error); ok && err != nil {
+				encoded.errs = append(encoded.errs, fieldError{key: f.Key, err: err})
+			}
+		}
+
+		if f.Type == zapcore.StringerType || f.Type == zapcore.ReflectType {
+			if v, ok := convertValue(f.Interface, converters); ok {
+				_ = enc.AddReflected(f.Key, convertedValue{v})
+				continue
+			}
+		}
+
 		f.AddTo(enc)
 	}
 
-	return ctx, enc.Fields
+	encoded.values = enc.Fields
+
+	return encoded
 }
@@ -1,6 +1,7 @@
 package sentryzapcore
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"time"
@@ -17,6 +18,10 @@ type valueSink interface {
 	SetInt(int)
 	SetInt64(int64)
 	SetFloat64(float64)
+	SetStringSlice([]string)
+	SetInt64Slice([]int64)
+	SetFloat64Slice([]float64)
+	SetBoolSlice([]bool)
 }
 
 // attributeSink populates an attribute.Builder (used during With()).
@@ -32,6 +37,18 @@ func (sink *attributeSink) SetInt64(value int64)   { sink.result = attribute.Int
 func (sink *attributeSink) SetFloat64(value float64) {
 	sink.result = attribute.Float64(sink.key, value)
 }
+func (sink *attributeSink) SetStringSlice(value []string) {
+	sink.result = attribute.StringSlice(sink.key, value)
+}
+func (sink *attributeSink) SetInt64Slice(value []int64) {
+	sink.result = attribute.Int64Slice(sink.key, value)
+}
+func (sink *attributeSink) SetFloat64Slice(value []float64) {
+	sink.result = attribute.Float64Slice(sink.key, value)
+}
+func (sink *attributeSink) SetBoolSlice(value []bool) {
+	sink.result = attribute.BoolSlice(sink.key, value)
+}
 
 // logEntrySink populates a sentry.LogEntry (used during Write()).
 type logEntrySink struct {
@@ -46,6 +63,18 @@ func (sink *logEntrySink) SetInt64(value int64)   { sink.entry = sink.entry.Int6
 func (sink *logEntrySink) SetFloat64(value float64) {
 	sink.entry = sink.entry.Float64(sink.key, value)
 }
+func (sink *logEntrySink) SetStringSlice(value []string) {
+	sink.entry = sink.entry.StringSlice(sink.key, value)
+}
+func (sink *logEntrySink) SetInt64Slice(value []int64) {
+	sink.entry = sink.entry.Int64Slice(sink.key, value)
+}
+func (sink *logEntrySink) SetFloat64Slice(value []float64) {
+	sink.entry = sink.entry.Float64Slice(sink.key, value)
+}
+func (sink *logEntrySink) SetBoolSlice(value []bool) {
+	sink.entry = sink.entry.BoolSlice(sink.key, value)
+}
 
 // attributeFromValue converts a single key/value pair to an attribute.Builder.
 func attributeFromValue(key string, value interface{}) attribute.Builder {
@@ -74,8 +103,121 @@ func attributesFromValues(values map[string]interface{}) []attribute.Builder {
 	return attrs
 }
 
+// ValueConverter converts field values of application types (for example
+// uuid.UUID, decimal.Decimal, net.IP or protobuf messages) before the
+// built-in conversions run. Register converters with WithValueConverter.
+type ValueConverter interface {
+	// ConvertValue returns the value to report instead of value and true,
+	// or false if it does not handle value. The returned value goes through
+	// the built-in conversions, so it may be a string, number, bool, slice,
+	// time.Time or a map[string]interface{}, which is flattened like a
+	// zap.Object field.
+	ConvertValue(value interface{}) (interface{}, bool)
+}
+
+// ValueConverterFunc is an adapter to allow the use of ordinary functions as
+// a ValueConverter.
+type ValueConverterFunc func(value interface{}) (interface{}, bool)
+
+// ConvertValue calls f(value).
+func (f ValueConverterFunc) ConvertValue(value interface{}) (interface{}, bool) {
+	return f(value)
+}
+
+// ConvertType returns a ValueConverter that handles values of type T with
+// convert, for example:
+//
+//	ConvertType(func(id uuid.UUID) interface{} { return id.String() })
+func ConvertType[T any](convert func(T) interface{}) ValueConverter {
+	return ValueConverterFunc(func(value interface{}) (interface{}, bool) {
+		v, ok := value.(T)
+		if !ok {
+			return nil, false
+		}
+
+		return convert(v), true
+	})
+}
+
+// convertValue returns the result of the first converter handling value.
+func convertValue(value interface{}, converters []ValueConverter) (interface{}, bool) {
+	for _, converter := range converters {
+		if v, ok := converter.ConvertValue(value); ok {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+// convertedValue wraps a value encodeFields already passed to the
+// converters, so that convertValues does not pass it to them again.
+type convertedValue struct {
+	value interface{}
+}
+
+// convertValues applies the converters to every value in place, descending
+// into nested maps. Values wrapped in convertedValue are unwrapped instead.
+func convertValues(values map[string]interface{}, converters []ValueConverter) {
+	if len(converters) == 0 {
+		return
+	}
+
+	for k, v := range values {
+		switch v := v.(type) {
+		case convertedValue:
+			values[k] = v.value
+			continue
+		case map[string]interface{}:
+			convertValues(v, converters)
+			continue
+		}
+
+		if converted, ok := convertValue(v, converters); ok {
+			values[k] = converted
+		}
+	}
+}
+
+const (
+	// defaultFieldSeparator joins the keys of nested fields.
+	defaultFieldSeparator = "."
+	// defaultMaxFieldDepth is the number of nesting levels flattened by default.
+	defaultMaxFieldDepth = 10
+)
+
+// flattenValues flattens the nested maps produced by zapcore.MapObjectEncoder
+// for zap.Object and zap.Namespace fields (including objects nested in them)
+// into keys joined by separator (for example "request.headers.host"), keeping
+// the leaf values' native types. Slices are kept whole so that applyValue can
+// report them as arrays. Values nested deeper than maxDepth levels are kept
+// as they are.
+func flattenValues(values map[string]interface{}, separator string, maxDepth int) map[string]interface{} {
+	flat := make(map[string]interface{}, len(values))
+
+	for k, v := range values {
+		flattenValue(flat, k, v, separator, maxDepth)
+	}
+
+	return flat
+}
+
+func flattenValue(flat map[string]interface{}, key string, value interface{}, separator string, depth int) {
+	if v, ok := value.(map[string]interface{}); ok && depth > 0 && len(v) > 0 {
+		for k, nested := range v {
+			flattenValue(flat, key+separator+k, nested, separator, depth-1)
+		}
+
+		return
+	}
+
+	flat[key] = value
+}
+
 // applyValue routes a value through type converters and writes the result
-// to the provided sink. Unknown types are converted via fmt.Sprint.
+// to the provided sink. Homogeneous slices of strings, integers, floats or
+// booleans become typed arrays; other slices (mixed or nested) and maps are
+// encoded as a JSON string. Unknown types are converted via fmt.Sprint.
 func applyValue(value interface{}, sink valueSink) {
 	if v, ok := timeStringValue(value); ok {
 		sink.SetString(v)
@@ -113,9 +255,105 @@ func applyValue(value interface{}, sink valueSink) {
 		return
 	}
 
+	if applySliceValue(value, sink) {
+		return
+	}
+
+	if v, ok := jsonValue(value); ok {
+		sink.SetString(v)
+		return
+	}
+
 	sink.SetString(fmt.Sprint(value))
 }
 
+// applySliceValue writes a homogeneous slice produced by zap.Array-style
+// fields (zap.Strings, zap.Int64s, zap.Bools, zap.Float64s, ...) to the sink
+// as a typed array. Time and duration elements count as strings. An empty
+// slice is written as an empty string array. It reports false for values
+// that are not such slices.
+func applySliceValue(value interface{}, sink valueSink) bool {
+	elems, ok := value.([]interface{})
+	if !ok {
+		return false
+	}
+
+	if v, ok := sliceOf(elems, stringElem); ok {
+		sink.SetStringSlice(v)
+		return true
+	}
+
+	if v, ok := sliceOf(elems, boolValue); ok {
+		sink.SetBoolSlice(v)
+		return true
+	}
+
+	if v, ok := sliceOf(elems, int64Elem); ok {
+		sink.SetInt64Slice(v)
+		return true
+	}
+
+	if v, ok := sliceOf(elems, float64Value); ok {
+		sink.SetFloat64Slice(v)
+		return true
+	}
+
+	return false
+}
+
+// sliceOf converts every element with convert, failing on the first element
+// it cannot convert.
+func sliceOf[T any](elems []interface{}, convert func(interface{}) (T, bool)) ([]T, bool) {
+	result := make([]T, 0, len(elems))
+
+	for _, elem := range elems {
+		v, ok := convert(elem)
+		if !ok {
+			return nil, false
+		}
+
+		result = append(result, v)
+	}
+
+	return result, true
+}
+
+func stringElem(value interface{}) (string, bool) {
+	if v, ok := timeStringValue(value); ok {
+		return v, true
+	}
+
+	return stringValue(value)
+}
+
+func int64Elem(value interface{}) (int64, bool) {
+	if v, ok := signedInt64Value(value); ok {
+		return v, true
+	}
+
+	if v, ok := unsignedInt64Value(value); ok && v <= math.MaxInt64 {
+		return int64(v), true
+	}
+
+	return 0, false
+}
+
+// jsonValue encodes mixed or nested slices and maps as a JSON string.
+func jsonValue(value interface{}) (string, bool) {
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+	default:
+		return "", false
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+
+	return string(b), true
+}
+
 func timeStringValue(value interface{}) (string, bool) {
 	switch v := value.(type) {
 	case time.Time:
@@ -134,7 +372,7 @@ func stringValue(value interface{}) (string, bool) {
 	case []byte:
 		return string(v), true
 	case error:
-		return v.Error(), true
+		return errorMessage(v), true
 	case fmt.Stringer:
 		return v.String(), true
 	default:
@@ -0,0 +1,219 @@
+package sentryzapcore
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type headers map[string]string
+
+func (h headers) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for k, v := range h {
+		enc.AddString(k, v)
+	}
+
+	return nil
+}
+
+type request struct {
+	Method  string
+	Status  int
+	Headers headers
+	Retries []int
+}
+
+func (r request) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("method", r.Method)
+	enc.AddInt("status", r.Status)
+
+	if err := enc.AddObject("headers", r.Headers); err != nil {
+		return err
+	}
+
+	return enc.AddArray("retries", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, n := range r.Retries {
+			arr.AppendInt(n)
+		}
+
+		return nil
+	}))
+}
+
+func TestNestedFields(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	req := request{Method: "GET", Status: 200, Headers: headers{"host": "example.com"}, Retries: []int{1, 2}}
+
+	t.Run("flattened with defaults", func(t *testing.T) {
+		logger := zap.New(NewSentryCore(context.Background(), WithHub(hub)))
+
+		message := gofakeit.Sentence()
+		logger.Error(message,
+			zap.Object("request", req),
+			zap.Namespace("db"),
+			zap.Bool("primary", true),
+		)
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, "GET", logEntry.Attributes["request.method"].String())
+		require.Equal(t, int64(200), logEntry.Attributes["request.status"].AsInt64())
+		require.Equal(t, "example.com", logEntry.Attributes["request.headers.host"].String())
+		require.Equal(t, []int64{1, 2}, logEntry.Attributes["request.retries"].AsInt64Slice())
+		require.True(t, logEntry.Attributes["db.primary"].AsBool())
+	})
+
+	t.Run("custom separator and depth", func(t *testing.T) {
+		logger := zap.New(NewSentryCore(context.Background(), WithHub(hub), WithFieldSeparator("/"), WithMaxFieldDepth(1)))
+
+		message := gofakeit.Sentence()
+		logger.Error(message, zap.Object("request", req))
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found)
+		require.Equal(t, "GET", logEntry.Attributes["request/method"].String())
+		require.Contains(t, logEntry.Attributes["request/headers"].String(), "example.com")
+		_, found = logEntry.Attributes["request/headers/host"]
+		require.False(t, found)
+	})
+}
+
+func TestFlattenValues(t *testing.T) {
+	values := map[string]interface{}{
+		"empty": map[string]interface{}{},
+		"list":  []interface{}{map[string]interface{}{"id": 1}},
+		"plain": "value",
+	}
+
+	require.Equal(t, values, flattenValues(values, ".", defaultMaxFieldDepth))
+
+	nested := map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}}}
+	require.Equal(t, map[string]interface{}{"a.b.c": 1}, flattenValues(nested, ".", defaultMaxFieldDepth))
+	require.Equal(t, map[string]interface{}{"a.b": map[string]interface{}{"c": 1}}, flattenValues(nested, ".", 1))
+
+	require.Equal(t, values, flattenValues(values, ".", 0))
+}
+
+func TestSliceFields(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	logger := zap.New(NewSentryCore(context.Background(), WithHub(hub)))
+
+	now := time.Now().UTC()
+
+	message := gofakeit.Sentence()
+	logger.Error(message,
+		zap.Strings("tags", []string{"a", "b"}),
+		zap.Int64s("ids", []int64{1, 2, 3}),
+		zap.Uints("counts", []uint{4, 5}),
+		zap.Bools("flags", []bool{true, false}),
+		zap.Float64s("ratios", []float64{0.5, 1.5}),
+		zap.Times("times", []time.Time{now}),
+		zap.Strings("none", nil),
+		zap.Array("mixed", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			arr.AppendString("a")
+			arr.AppendInt(1)
+
+			return nil
+		})),
+		zap.Array("objects", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			return arr.AppendObject(headers{"host": "example.com"})
+		})),
+	)
+	hub.Flush(2 * time.Second)
+
+	logEntry, found := findLog(transport.Events(), message)
+	require.True(t, found)
+	require.Equal(t, []string{"a", "b"}, logEntry.Attributes["tags"].AsStringSlice())
+	require.Equal(t, []int64{1, 2, 3}, logEntry.Attributes["ids"].AsInt64Slice())
+	require.Equal(t, []int64{4, 5}, logEntry.Attributes["counts"].AsInt64Slice())
+	require.Equal(t, []bool{true, false}, logEntry.Attributes["flags"].AsBoolSlice())
+	require.Equal(t, []float64{0.5, 1.5}, logEntry.Attributes["ratios"].AsFloat64Slice())
+	require.Equal(t, []string{now.Format(time.RFC3339Nano)}, logEntry.Attributes["times"].AsStringSlice())
+	require.Empty(t, logEntry.Attributes["none"].AsStringSlice())
+	require.JSONEq(t, `["a",1]`, logEntry.Attributes["mixed"].String())
+	require.JSONEq(t, `[{"host":"example.com"}]`, logEntry.Attributes["objects"].String())
+}
+
+func TestSliceAttributesFromValues(t *testing.T) {
+	attr := attributeFromValue("tags", []interface{}{"a", "b"})
+	require.Equal(t, []string{"a", "b"}, attr.Value.AsStringSlice())
+
+	attr = attributeFromValue("nested", []interface{}{[]interface{}{1}, []interface{}{2}})
+	require.Equal(t, "[[1],[2]]", attr.Value.String())
+}
+
+type money struct {
+	Units    int64
+	Currency string
+}
+
+func TestValueConverters(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	logger := zap.New(NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithValueConverter(
+			ConvertType(func(ip net.IP) interface{} { return "ip:" + ip.String() }),
+			ConvertType(func(m money) interface{} {
+				return map[string]interface{}{"units": m.Units, "currency": m.Currency}
+			}),
+			ConvertType(func(d time.Duration) interface{} { return d.Milliseconds() }),
+		),
+	)).With(zap.Stringer("peer", net.ParseIP("10.0.0.1")))
+
+	message := gofakeit.Sentence()
+	logger.Error(message,
+		zap.Any("client", net.ParseIP("192.168.0.1")),
+		zap.Any("price", money{Units: 42, Currency: "EUR"}),
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.String("plain", "unchanged"),
+	)
+	hub.Flush(2 * time.Second)
+
+	logEntry, found := findLog(transport.Events(), message)
+	require.True(t, found)
+	require.Equal(t, "ip:10.0.0.1", logEntry.Attributes["peer"].String())
+	require.Equal(t, "ip:192.168.0.1", logEntry.Attributes["client"].String())
+	require.Equal(t, int64(42), logEntry.Attributes["price.units"].AsInt64())
+	require.Equal(t, "EUR", logEntry.Attributes["price.currency"].String())
+	require.Equal(t, int64(1500), logEntry.Attributes["elapsed"].AsInt64())
+	require.Equal(t, "unchanged", logEntry.Attributes["plain"].String())
+}
+
+func TestValueConvertersRunOnce(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	logger := zap.New(NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithValueConverter(
+			ConvertType(func(ip net.IP) interface{} { return ip.String() }),
+			ConvertType(func(s string) interface{} { return "<" + s + ">" }),
+		),
+	))
+
+	message := gofakeit.Sentence()
+	logger.Error(message,
+		zap.Stringer("ip", net.ParseIP("1.2.3.4")),
+		zap.Namespace("peer"),
+		zap.Any("ip", net.ParseIP("5.6.7.8")),
+	)
+	hub.Flush(2 * time.Second)
+
+	logEntry, found := findLog(transport.Events(), message)
+	require.True(t, found)
+	require.Equal(t, "1.2.3.4", logEntry.Attributes["ip"].String())
+	require.Equal(t, "5.6.7.8", logEntry.Attributes["peer.ip"].String())
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -20,7 +21,8 @@ var _ sentry.Transport = (*transportMock)(nil)
 
 type transportMock struct {
 	sync.Mutex
-	events []*sentry.Event
+	events  []*sentry.Event
+	flushes int
 }
 
 func (*transportMock) Configure(_ sentry.ClientOptions) { /* stub */ }
@@ -29,7 +31,10 @@ func (t *transportMock) SendEvent(event *sentry.Event) {
 	defer t.Unlock()
 	t.events = append(t.events, event)
 }
-func (*transportMock) Flush(_ time.Duration) bool {
+func (t *transportMock) Flush(_ time.Duration) bool {
+	t.Lock()
+	defer t.Unlock()
+	t.flushes++
 	return true
 }
 func (t *transportMock) FlushWithContext(_ context.Context) bool {
@@ -41,6 +46,11 @@ func (t *transportMock) Events() []*sentry.Event {
 	defer t.Unlock()
 	return t.events
 }
+func (t *transportMock) Flushes() int {
+	t.Lock()
+	defer t.Unlock()
+	return t.flushes
+}
 func (*transportMock) Close() {
 	/* stub */
 }
@@ -405,3 +415,132 @@ func TestConversionHelpers(t *testing.T) {
 		t.Errorf("unsignedInt64Value(string) = (%d, %v), want (0, false)", n, ok)
 	}
 }
+
+func TestBeforeEmit(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	logger := zap.New(NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithEventLevel(zapcore.ErrorLevel),
+		WithBeforeEmit(func(entry zapcore.Entry, attrs map[string]interface{}) (zapcore.Entry, map[string]interface{}, bool) {
+			if entry.LoggerName == "healthcheck" {
+				return entry, attrs, false
+			}
+
+			entry.Message = "[" + entry.LoggerName + "] " + entry.Message
+			attrs["caller.function"] = entry.Caller.Function
+			delete(attrs, "internal")
+
+			return entry, attrs, true
+		}),
+	), zap.AddCaller())
+
+	message := gofakeit.Sentence()
+	logger.Named("api").Error(message, zap.String("internal", "x"), zap.Int("status", 500))
+	hub.Flush(2 * time.Second)
+
+	logEntry, found := findLog(transport.Events(), "[api] "+message)
+	require.True(t, found)
+	require.Equal(t, int64(500), logEntry.Attributes["status"].AsInt64())
+	require.Contains(t, logEntry.Attributes["caller.function"].String(), "TestBeforeEmit")
+	require.NotContains(t, logEntry.Attributes, "internal")
+
+	_, found = findEvent(transport.Events(), "[api] "+message)
+	require.True(t, found)
+
+	vetoed := gofakeit.Sentence()
+	logger.Named("healthcheck").Error(vetoed)
+	hub.Flush(2 * time.Second)
+
+	_, found = findLog(transport.Events(), "[healthcheck] "+vetoed)
+	require.False(t, found)
+	_, found = findEvent(transport.Events(), vetoed)
+	require.False(t, found)
+}
+
+func TestLevelMapper(t *testing.T) {
+	const (
+		traceLevel = zapcore.DebugLevel - 1
+		alertLevel = zapcore.FatalLevel + 1
+	)
+
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	logger := zap.New(NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithMinLevel(traceLevel),
+		WithEventLevel(zapcore.DPanicLevel),
+		WithLevelMapper(func(level zapcore.Level) sentry.LogLevel {
+			switch {
+			case level < zapcore.DebugLevel:
+				return sentry.LogLevelTrace
+			case level >= zapcore.DPanicLevel:
+				return sentry.LogLevelFatal
+			default:
+				return sentry.LogLevelWarn
+			}
+		}),
+	))
+
+	cases := []struct {
+		level     zapcore.Level
+		want      sentry.LogLevel
+		wantLevel string
+	}{
+		{traceLevel, sentry.LogLevelTrace, "Level(-2)"},
+		{zapcore.InfoLevel, sentry.LogLevelWarn, "info"},
+		{zapcore.DPanicLevel, sentry.LogLevelFatal, "dpanic"},
+		{alertLevel, sentry.LogLevelFatal, "Level(6)"},
+	}
+
+	for _, tt := range cases {
+		message := gofakeit.Sentence()
+		logger.Log(tt.level, message)
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found, tt.wantLevel)
+		require.Equal(t, tt.want, logEntry.Level, tt.wantLevel)
+		require.Equal(t, tt.wantLevel, logEntry.Attributes["zap.level"].String())
+
+		event, found := findEvent(transport.Events(), message)
+		require.Equal(t, tt.level >= zapcore.DPanicLevel, found, tt.wantLevel)
+
+		if found {
+			require.Equal(t, sentry.LevelFatal, event.Level)
+			require.Equal(t, tt.wantLevel, event.Tags["zap.level"])
+		}
+	}
+
+	// A Level field takes precedence over the mapper.
+	message := gofakeit.Sentence()
+	logger.Info(message, Level(sentry.LevelError))
+	hub.Flush(2 * time.Second)
+
+	logEntry, found := findLog(transport.Events(), message)
+	require.True(t, found)
+	require.Equal(t, sentry.LogLevelError, logEntry.Level)
+}
+
+func TestCustomLevelsWithoutMapper(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	logger := zap.New(NewSentryCore(context.Background(), WithHub(hub), WithMinLevel(zapcore.DebugLevel-2)))
+
+	for level, want := range map[zapcore.Level]sentry.LogLevel{
+		zapcore.DebugLevel - 2: sentry.LogLevelDebug,
+		zapcore.DebugLevel - 1: sentry.LogLevelDebug,
+		zapcore.FatalLevel + 1: sentry.LogLevelError,
+	} {
+		message := gofakeit.Sentence()
+		logger.Log(level, message)
+		hub.Flush(2 * time.Second)
+
+		logEntry, found := findLog(transport.Events(), message)
+		require.True(t, found, level.String())
+		require.Equal(t, want, logEntry.Level, level.String())
+	}
+}
@@ -0,0 +1,307 @@
+package sentryzapcore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SinkScheme is the conventional URL scheme of the Sentry sink. Once
+// registered with RegisterSink(SinkScheme), a zap.Config can forward its
+// entries to Sentry by listing a "sentry://" output path.
+const SinkScheme = "sentry"
+
+// errUnknownSinkOption is returned for a sink URL query parameter that is not
+// an option.
+var errUnknownSinkOption = errors.New("unknown option")
+
+// sinkKeys are the keys the sink reads the entry from, matching the
+// EncoderConfig of the zap.Config.
+type sinkKeys struct {
+	message    string
+	level      string
+	time       string
+	logger     string
+	caller     string
+	stacktrace string
+}
+
+// sink is a zap.Sink that decodes JSON-encoded entries and writes them to a
+// SentryCore. It is used through the "sentry://" output path.
+type sink struct {
+	core *SentryCore
+	keys sinkKeys
+}
+
+// RegisterSink registers a Sentry sink with zap for the given URL scheme,
+// typically SinkScheme. It returns an error if the scheme is already
+// registered. The options apply to every sink of the scheme, before the ones of the
+// URL; for example, a "sentry-payments" scheme can be bound to its own client
+// with WithClient.
+//
+// The sink decodes the entries written to it, so the zap.Config must use the
+// JSON encoding. Its URL query sets the options of the core:
+//
+//	level=warn                    WithMinLevel
+//	stacktrace=true               WithStackTrace
+//	event_level=error             WithEventLevel
+//	breadcrumbs=info              WithBreadcrumbs
+//	loggers=db=warn,http=debug    WithLoggerLevels (URL-encoded)
+//	flush_timeout=5s              WithFlushTimeout
+//	field_separator=_             WithFieldSeparator
+//
+// and, when the EncoderConfig does not use the zap.NewProductionEncoderConfig
+// keys, the keys to read the entry from: message_key, level_key, time_key,
+// name_key, caller_key and stacktrace_key.
+func RegisterSink(scheme string, options ...SentryCoreOptions) error {
+	return zap.RegisterSink(scheme, func(u *url.URL) (zap.Sink, error) {
+		return newSink(u, options)
+	})
+}
+
+func newSink(u *url.URL, options []SentryCoreOptions) (*sink, error) {
+	s := &sink{keys: sinkKeys{
+		message:    "msg",
+		level:      "level",
+		time:       "ts",
+		logger:     "logger",
+		caller:     "caller",
+		stacktrace: "stacktrace",
+	}}
+
+	options = append([]SentryCoreOptions(nil), options...)
+
+	for name, values := range u.Query() {
+		value := values[len(values)-1]
+
+		option, err := s.parseOption(name, value)
+		if err != nil {
+			return nil, fmt.Errorf("sentryzapcore: sink option %s=%q: %w", name, value, err)
+		}
+
+		if option != nil {
+			options = append(options, option)
+		}
+	}
+
+	s.core = NewSentryCore(context.Background(), options...)
+
+	return s, nil
+}
+
+// parseOption returns the core option of a query parameter, or records the
+// key it sets.
+func (s *sink) parseOption(name, value string) (SentryCoreOptions, error) {
+	switch name {
+	case "level", "event_level", "breadcrumbs":
+		level, err := zapcore.ParseLevel(value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "level":
+			return WithMinLevel(level), nil
+		case "event_level":
+			return WithEventLevel(level), nil
+		default:
+			return WithBreadcrumbs(level, 0), nil
+		}
+	case "stacktrace":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil || !enabled {
+			return nil, err
+		}
+
+		return WithStackTrace(), nil
+	case "loggers":
+		levels, err := ParseLoggerLevels(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return WithLoggerLevels(levels), nil
+	case "flush_timeout":
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return WithFlushTimeout(timeout), nil
+	case "field_separator":
+		return WithFieldSeparator(value), nil
+	case "message_key":
+		s.keys.message = value
+	case "level_key":
+		s.keys.level = value
+	case "time_key":
+		s.keys.time = value
+	case "name_key":
+		s.keys.logger = value
+	case "caller_key":
+		s.keys.caller = value
+	case "stacktrace_key":
+		s.keys.stacktrace = value
+	default:
+		return nil, errUnknownSinkOption
+	}
+
+	return nil, nil
+}
+
+// Write decodes the JSON-encoded entries, one per line, and writes them to
+// the core. Entries the core does not enable are dropped.
+// It implements the io.Writer interface.
+func (s *sink) Write(p []byte) (int, error) {
+	for line := range bytes.Lines(p) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		entry, fields, err := s.decode(line)
+		if err != nil {
+			return 0, err
+		}
+
+		if !s.core.entryEnabled(entry) {
+			continue
+		}
+
+		if err := s.core.Write(entry, fields); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// decode reads an entry and its fields from a JSON object. Numbers become
+// int64 when they are integers, float64 otherwise.
+func (s *sink) decode(line []byte) (zapcore.Entry, []zapcore.Field, error) {
+	var object map[string]interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	if err := dec.Decode(&object); err != nil {
+		return zapcore.Entry{}, nil, fmt.Errorf("sentryzapcore: sink expects JSON-encoded entries: %w", err)
+	}
+
+	entry := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now()}
+	fields := make([]zapcore.Field, 0, len(object))
+
+	for key, value := range object {
+		text, isText := value.(string)
+
+		switch {
+		case key == s.keys.message && isText:
+			entry.Message = text
+		case key == s.keys.level && isText:
+			if level, err := zapcore.ParseLevel(text); err == nil {
+				entry.Level = level
+			}
+		case key == s.keys.time:
+			if t, ok := decodeTime(value); ok {
+				entry.Time = t
+			}
+		case key == s.keys.logger && isText:
+			entry.LoggerName = text
+		case key == s.keys.caller && isText:
+			entry.Caller = decodeCaller(text)
+		case key == s.keys.stacktrace && isText:
+			entry.Stack = text
+		default:
+			fields = append(fields, zap.Any(key, decodeNumbers(value)))
+		}
+	}
+
+	return entry, fields, nil
+}
+
+// Sync flushes the core.
+// It implements the zapcore.WriteSyncer interface.
+func (s *sink) Sync() error {
+	return s.core.Sync()
+}
+
+// Close flushes the core and stops its workers.
+// It implements the io.Closer interface.
+func (s *sink) Close() error {
+	return s.core.Close()
+}
+
+// decodeTime reads a time encoded as epoch seconds, the zap default, or as
+// an ISO 8601 or RFC 3339 string.
+func decodeTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		seconds, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		whole, frac := math.Modf(seconds)
+
+		return time.Unix(int64(whole), int64(frac*float64(time.Second))), true
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+
+		return time.Time{}, false
+	default:
+		return time.Time{}, false
+	}
+}
+
+// decodeCaller reads a caller encoded as "file:line".
+func decodeCaller(text string) zapcore.EntryCaller {
+	i := strings.LastIndexByte(text, ':')
+	if i < 0 {
+		return zapcore.EntryCaller{Defined: true, File: text}
+	}
+
+	line, err := strconv.Atoi(text[i+1:])
+	if err != nil {
+		return zapcore.EntryCaller{Defined: true, File: text}
+	}
+
+	return zapcore.EntryCaller{Defined: true, File: text[:i], Line: line}
+}
+
+// decodeNumbers replaces the json.Numbers of a decoded value.
+func decodeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+
+		f, _ := v.Float64()
+
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = decodeNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = decodeNumbers(item)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,139 @@
+package sentryzapcore
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSink(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	require.NoError(t, RegisterSink("sentry-sink-test", WithHub(hub)))
+
+	cfg := zap.NewProductionConfig()
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	cfg.OutputPaths = []string{"sentry-sink-test://?level=info&stacktrace=true&event_level=error&loggers=db%3Derror"}
+
+	logger, err := cfg.Build()
+	require.NoError(t, err)
+
+	debug := gofakeit.Sentence()
+	info := gofakeit.Sentence()
+	quietened := gofakeit.Sentence()
+	failure := gofakeit.Sentence()
+
+	logger.Debug(debug)
+	logger.Info(info, zap.Int("items", 3), zap.Float64("ratio", 0.5), zap.Object("cart", zapcore.ObjectMarshalerFunc(
+		func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("id", "c-1")
+			return nil
+		})))
+	logger.Named("db").Warn(quietened)
+	logger.Named("checkout").Error(failure)
+	require.NoError(t, logger.Sync())
+
+	_, found := findLog(transport.Events(), debug)
+	require.False(t, found)
+	_, found = findLog(transport.Events(), quietened)
+	require.False(t, found)
+
+	logEntry, found := findLog(transport.Events(), info)
+	require.True(t, found)
+	require.Equal(t, int64(3), logEntry.Attributes["items"].AsInt64())
+	require.InDelta(t, 0.5, logEntry.Attributes["ratio"].AsFloat64(), 0)
+	require.Equal(t, "c-1", logEntry.Attributes["cart.id"].String())
+	require.Contains(t, logEntry.Attributes["caller.file"].String(), "sink_test.go")
+
+	logEntry, found = findLog(transport.Events(), failure)
+	require.True(t, found)
+	require.Equal(t, "checkout", logEntry.Attributes["logger"].String())
+	require.Contains(t, logEntry.Attributes["stacktrace"].String(), "TestSink")
+
+	event, found := findEvent(transport.Events(), failure)
+	require.True(t, found)
+	require.Equal(t, "checkout", event.Logger)
+}
+
+func TestSinkOptions(t *testing.T) {
+	require.NoError(t, RegisterSink("sentry-sink-options-test"))
+
+	cfg := zap.NewProductionConfig()
+
+	for _, path := range []string{
+		"sentry-sink-options-test://?level=loud",
+		"sentry-sink-options-test://?loggers=db",
+		"sentry-sink-options-test://?flush_timeout=soon",
+		"sentry-sink-options-test://?colour=blue",
+	} {
+		cfg.OutputPaths = []string{path}
+
+		_, err := cfg.Build()
+		require.ErrorContains(t, err, "sink option", path)
+	}
+}
+
+func TestRegisterSinkTwice(t *testing.T) {
+	require.NoError(t, RegisterSink("sentry-sink-twice-test"))
+	require.Error(t, RegisterSink("sentry-sink-twice-test"))
+}
+
+func TestSinkKeys(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	require.NoError(t, RegisterSink("sentry-sink-keys-test", WithHub(hub)))
+
+	cfg := zap.NewDevelopmentConfig()
+	cfg.Encoding = "json"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	cfg.OutputPaths = []string{"sentry-sink-keys-test://?level=warn&message_key=M&level_key=L&time_key=T&name_key=N&caller_key=C&stacktrace_key=S"}
+
+	logger, err := cfg.Build()
+	require.NoError(t, err)
+
+	message := gofakeit.Sentence()
+	logger.Named("api").Warn(message)
+	require.NoError(t, logger.Sync())
+
+	logEntry, found := findLog(transport.Events(), message)
+	require.True(t, found)
+	require.Equal(t, sentry.LogLevelWarn, logEntry.Level)
+	require.Equal(t, "api", logEntry.Attributes["logger"].String())
+}
+
+func TestSinkDecode(t *testing.T) {
+	s, err := newSink(mustParseURL(t, "sentry://"), nil)
+	require.NoError(t, err)
+
+	entry, fields, err := s.decode([]byte(`{"level":"warn","ts":1767323045.5,"logger":"api","caller":"shop/cart.go:42","msg":"slow","n":{"a":[1,2.5]}}`))
+	require.NoError(t, err)
+	require.Equal(t, zapcore.WarnLevel, entry.Level)
+	require.Equal(t, time.Unix(1767323045, int64(500*time.Millisecond)), entry.Time)
+	require.Equal(t, "api", entry.LoggerName)
+	require.Equal(t, zapcore.EntryCaller{Defined: true, File: "shop/cart.go", Line: 42}, entry.Caller)
+	require.Equal(t, "slow", entry.Message)
+	require.Equal(t, []zapcore.Field{zap.Any("n", map[string]interface{}{"a": []interface{}{int64(1), 2.5}})}, fields)
+
+	entry, _, err = s.decode([]byte(`{"ts":"2026-01-02T03:04:05.000+0100","msg":"iso"}`))
+	require.NoError(t, err)
+	require.Equal(t, zapcore.InfoLevel, entry.Level)
+	require.True(t, time.Date(2026, 1, 2, 2, 4, 5, 0, time.UTC).Equal(entry.Time))
+
+	_, _, err = s.decode([]byte("INFO\tconsole output"))
+	require.ErrorContains(t, err, "JSON-encoded")
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+
+	return u
+}
@@ -0,0 +1,161 @@
+package sentryzapcore
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"runtime"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Ensure SlogHandler implements slog.Handler interface.
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// SlogHandler is a slog.Handler that sends records to Sentry through a
+// SentryCore, so that log/slog and zap loggers sharing a core produce the
+// same Sentry output: levels, attribute conversion, scrubbing, stack traces
+// and the other options of the core apply alike.
+//
+// Attributes of groups become keys joined by the core's field separator, "."
+// by default. The context passed to the logging call links the record to its
+// trace, as the Context field does for zap. Fields built by the field
+// constructors, such as Tag or User, can be passed as slog.Any values.
+type SlogHandler struct {
+	core   *SentryCore
+	prefix string // key prefix of the open groups
+}
+
+// NewSlogHandler creates a SlogHandler that writes to core.
+func NewSlogHandler(core *SentryCore) *SlogHandler {
+	return &SlogHandler{core: core}
+}
+
+// Enabled reports whether the core handles records at the given level.
+// It implements the slog.Handler interface.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevelForSlogLevel(level))
+}
+
+// Handle sends the record to Sentry.
+// It implements the slog.Handler interface.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := zapcore.Entry{
+		Level:   zapLevelForSlogLevel(record.Level),
+		Time:    record.Time,
+		Message: record.Message,
+	}
+
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		}
+	}
+
+	if !h.core.entryEnabled(entry) {
+		return nil
+	}
+
+	fields := make([]zapcore.Field, 0, record.NumAttrs()+1)
+
+	if ctx != nil && (sentry.SpanFromContext(ctx) != nil || sentry.GetHubFromContext(ctx) != nil) {
+		fields = append(fields, Context(ctx))
+	}
+
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = h.appendAttr(fields, h.prefix, attr)
+		return true
+	})
+
+	return h.core.Write(entry, fields)
+}
+
+// WithAttrs returns a handler whose records carry the attributes.
+// It implements the slog.Handler interface.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	fields := make([]zapcore.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = h.appendAttr(fields, h.prefix, attr)
+	}
+
+	return &SlogHandler{core: h.core.With(fields).(*SentryCore), prefix: h.prefix}
+}
+
+// WithGroup returns a handler that prefixes the keys of the later attributes
+// with the group name.
+// It implements the slog.Handler interface.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &SlogHandler{core: h.core, prefix: h.prefix + name + h.core.fieldSeparator}
+}
+
+// appendAttr appends the zap fields of an attribute, with its key prefixed.
+// Groups are flattened, and empty attributes and groups are skipped, as
+// slog.Handler requires.
+func (h *SlogHandler) appendAttr(fields []zapcore.Field, prefix string, attr slog.Attr) []zapcore.Field {
+	value := attr.Value.Resolve()
+	key := prefix + attr.Key
+
+	switch value.Kind() {
+	case slog.KindGroup:
+		if attr.Key != "" {
+			prefix = key + h.core.fieldSeparator
+		}
+
+		for _, member := range value.Group() {
+			fields = h.appendAttr(fields, prefix, member)
+		}
+
+		return fields
+	case slog.KindString:
+		return append(fields, zap.String(key, value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(key, value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(key, value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(key, value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(key, value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(key, value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(key, value.Time()))
+	}
+
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch v := value.Any().(type) {
+	case zapcore.Field:
+		return append(fields, v)
+	case error:
+		return append(fields, zap.NamedError(key, v))
+	default:
+		return append(fields, zap.Any(key, v))
+	}
+}
+
+// zapLevelForSlogLevel returns the zap level of a slog level: Debug, Info,
+// Warn and Error map to their zap counterparts, and every step of 4 beyond
+// them to the next zap level, so that slog.LevelError+4 is DPanic.
+func zapLevelForSlogLevel(level slog.Level) zapcore.Level {
+	n := math.Floor(float64(level) / 4)
+
+	return zapcore.Level(max(math.MinInt8, min(math.MaxInt8, n)))
+}
@@ -0,0 +1,150 @@
+package sentryzapcore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adlandh/sentry-zapcore/v2/internal/stacktest"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/getsentry/sentry-go/attribute"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// comparableAttributes drops the attributes that differ between two calls
+// of the same entry.
+func comparableAttributes(attrs map[string]attribute.Value) map[string]interface{} {
+	values := make(map[string]interface{}, len(attrs))
+
+	for k, v := range attrs {
+		if !strings.HasPrefix(k, "caller.") && !strings.HasPrefix(k, "sentry.") {
+			values[k] = v.AsInterface()
+		}
+	}
+
+	return values
+}
+
+func TestSlogHandlerMatchesCore(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithMinLevel(zapcore.InfoLevel),
+		WithEventLevel(zapcore.ErrorLevel),
+		WithScrubber(mustScrubber(t, DenyKeys("password"))),
+	)
+
+	zapMessage := gofakeit.Sentence()
+	slogMessage := gofakeit.Sentence()
+	err := errors.New("boom")
+
+	zap.New(core).With(zap.String("service", "shop"), zap.Dict("request", zap.String("id", "r-1"))).
+		Error(zapMessage,
+			zap.Error(err),
+			Tag("region", "eu"),
+			zap.Namespace("request"),
+			zap.Int64("items", 3),
+			zap.Bool("retry", true),
+			zap.Duration("elapsed", time.Second),
+			zap.String("password", "hunter2"),
+		)
+
+	slog.New(NewSlogHandler(core)).With("service", "shop", slog.Group("request", "id", "r-1")).
+		Error(slogMessage,
+			slog.Any("error", err),
+			slog.Any("", Tag("region", "eu")),
+			slog.Group("request",
+				"items", 3,
+				"retry", true,
+				"elapsed", time.Second,
+				"password", "hunter2",
+			),
+		)
+
+	hub.Flush(2 * time.Second)
+
+	zapLog, found := findLog(transport.Events(), zapMessage)
+	require.True(t, found)
+	slogLog, found := findLog(transport.Events(), slogMessage)
+	require.True(t, found)
+
+	require.Equal(t, zapLog.Level, slogLog.Level)
+	require.Equal(t, comparableAttributes(zapLog.Attributes), comparableAttributes(slogLog.Attributes))
+
+	zapEvent, found := findEvent(transport.Events(), zapMessage)
+	require.True(t, found)
+	slogEvent, found := findEvent(transport.Events(), slogMessage)
+	require.True(t, found)
+
+	require.Equal(t, zapEvent.Level, slogEvent.Level)
+	require.Equal(t, zapEvent.Tags, slogEvent.Tags)
+	require.Equal(t, zapEvent.Contexts["fields"], slogEvent.Contexts["fields"])
+	require.Equal(t, zapEvent.Exception[0].Value, slogEvent.Exception[0].Value)
+}
+
+func mustScrubber(t *testing.T, options ...ScrubberOption) *Scrubber {
+	t.Helper()
+
+	scrubber, err := NewScrubber(options...)
+	require.NoError(t, err)
+
+	return scrubber
+}
+
+func TestSlogHandlerContext(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	logger := slog.New(NewSlogHandler(NewSentryCore(ctx, WithEventLevel(zapcore.ErrorLevel), WithStackTrace())))
+
+	span := sentry.StartSpan(ctx, gofakeit.Word())
+	defer span.Finish()
+
+	message := gofakeit.Sentence()
+	stacktest.Call(func() {
+		logger.WithGroup("request").ErrorContext(span.Context(), message, slog.Group("", "inlined", 1), slog.Group("empty"), slog.Attr{})
+	})
+	hub.Flush(2 * time.Second)
+
+	event, found := findEvent(transport.Events(), message)
+	require.True(t, found)
+	require.Equal(t, span.TraceID, event.Contexts["trace"]["trace_id"])
+	require.Equal(t, map[string]interface{}{"request.inlined": int64(1)}, event.Contexts["fields"])
+
+	requireStackTop(t, event.Threads[0].Stacktrace)
+
+	logEntry, found := findLog(transport.Events(), message)
+	require.True(t, found)
+	require.Equal(t, span.TraceID, logEntry.TraceID)
+	require.Contains(t, logEntry.Attributes["caller.file"].String(), "slog_test.go")
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	cases := map[slog.Level]zapcore.Level{
+		slog.LevelDebug - 4:  zapcore.DebugLevel - 1,
+		slog.LevelDebug:      zapcore.DebugLevel,
+		slog.LevelInfo:       zapcore.InfoLevel,
+		slog.LevelInfo + 2:   zapcore.InfoLevel,
+		slog.LevelWarn:       zapcore.WarnLevel,
+		slog.LevelError:      zapcore.ErrorLevel,
+		slog.LevelError + 4:  zapcore.DPanicLevel,
+		slog.LevelError + 12: zapcore.FatalLevel,
+	}
+
+	for level, want := range cases {
+		require.Equal(t, want, zapLevelForSlogLevel(level), level.String())
+	}
+
+	handler := NewSlogHandler(NewSentryCore(context.Background(), WithMinLevel(zapcore.WarnLevel)))
+	require.False(t, handler.Enabled(context.Background(), slog.LevelInfo))
+	require.True(t, handler.Enabled(context.Background(), slog.LevelWarn))
+}
@@ -0,0 +1,170 @@
+package sentryzapcore
+
+import (
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// trimmedPackages are the packages whose frames are trimmed off the top of
+// parsed stacks: the logging machinery between the log call and the core.
+// As in go list patterns, a "/..." suffix also matches the subpackages.
+var trimmedPackages = []string{
+	"github.com/adlandh/sentry-zapcore/v2",
+	"go.uber.org/zap/...",
+	"log/slog",
+	"runtime/...",
+}
+
+// stacktraceFromString parses a stack formatted by zap (entry.Stack) or by
+// runtime/debug.Stack into a sentry.Stacktrace. Frames of this package, zap
+// and the runtime are trimmed off the top of the stack. A frame is in_app
+// when its module has one of inAppPrefixes, or, without prefixes, when it is
+// not part of the Go standard library or a vendored package. It returns nil
+// if no frame is left.
+func stacktraceFromString(stack string, inAppPrefixes []string) *sentry.Stacktrace {
+	frames := trimStackTop(parseStack(stack))
+	if len(frames) == 0 {
+		return nil
+	}
+
+	// Sentry expects the outermost call first.
+	slices.Reverse(frames)
+
+	result := make([]sentry.Frame, 0, len(frames))
+	for _, f := range frames {
+		frame := sentry.NewFrame(f)
+		if len(inAppPrefixes) > 0 {
+			frame.InApp = hasAnyPrefix(frame.Module, inAppPrefixes)
+		}
+
+		result = append(result, frame)
+	}
+
+	return &sentry.Stacktrace{Frames: result}
+}
+
+// parseStack parses a textual Go stack into frames, innermost call first.
+// Each frame is a function line followed by a tab-indented "file:line" line,
+// optionally suffixed with a "+0x..." offset. Goroutine headers and
+// argument lists are ignored.
+func parseStack(stack string) []runtime.Frame {
+	lines := strings.Split(stack, "\n")
+	frames := make([]runtime.Frame, 0, len(lines)/2)
+
+	for i := 0; i < len(lines); i++ {
+		function := strings.TrimSpace(lines[i])
+		if function == "" || strings.HasPrefix(function, "goroutine ") || strings.HasPrefix(lines[i], "\t") {
+			continue
+		}
+
+		function = strings.TrimPrefix(function, "created by ")
+		if j := strings.Index(function, " in goroutine "); j >= 0 {
+			function = function[:j]
+		}
+
+		if strings.HasSuffix(function, ")") {
+			if j := strings.LastIndex(function, "("); j > 0 {
+				function = function[:j]
+			}
+		}
+
+		frame := runtime.Frame{Function: function}
+
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+			frame.File, frame.Line = parseStackLocation(strings.TrimSpace(lines[i+1]))
+			i++
+		}
+
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+// parseStackLocation splits "file.go:12 +0x1d" into its file and line.
+func parseStackLocation(location string) (string, int) {
+	if j := strings.Index(location, " +0x"); j >= 0 {
+		location = location[:j]
+	}
+
+	j := strings.LastIndex(location, ":")
+	if j < 0 {
+		return location, 0
+	}
+
+	line, err := strconv.Atoi(location[j+1:])
+	if err != nil {
+		return location, 0
+	}
+
+	return location[:j], line
+}
+
+// trimStackTop drops the innermost frames that belong to trimmedPackages.
+func trimStackTop(frames []runtime.Frame) []runtime.Frame {
+	for len(frames) > 0 {
+		frame := frames[0]
+		if !isTrimmedPackage(functionModule(frame.Function)) {
+			break
+		}
+
+		frames = frames[1:]
+	}
+
+	return frames
+}
+
+// trimFramesTop is trimStackTop for Sentry frames, which are ordered
+// outermost call first.
+func trimFramesTop(frames []sentry.Frame) []sentry.Frame {
+	for len(frames) > 0 {
+		frame := frames[len(frames)-1]
+		if !isTrimmedPackage(frame.Module) {
+			break
+		}
+
+		frames = frames[:len(frames)-1]
+	}
+
+	return frames
+}
+
+// functionModule returns the package path of a qualified function name,
+// such as "go.uber.org/zap" for "go.uber.org/zap.(*Logger).Error".
+func functionModule(function string) string {
+	lastSlash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[lastSlash+1:], "."); dot >= 0 {
+		return function[:lastSlash+1+dot]
+	}
+
+	return function
+}
+
+// isTrimmedPackage reports whether the package matches trimmedPackages.
+func isTrimmedPackage(pkg string) bool {
+	for _, pattern := range trimmedPackages {
+		if base, ok := strings.CutSuffix(pattern, "/..."); ok {
+			if pkg == base || strings.HasPrefix(pkg, base+"/") {
+				return true
+			}
+		} else if pkg == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+package sentryzapcore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/adlandh/sentry-zapcore/v2/internal/stacktest"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const zapStack = `go.uber.org/zap.(*Logger).Error
+	/go/pkg/mod/go.uber.org/zap@v1.28.0/logger.go:270
+example.com/app/handler.(*Server).Serve
+	/src/app/handler/server.go:42
+net/http.HandlerFunc.ServeHTTP
+	/usr/local/go/src/net/http/server.go:2220
+main.main
+	/src/app/main.go:10`
+
+const debugStack = `goroutine 7 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:26 +0x5e
+github.com/adlandh/sentry-zapcore/v2.(*SentryCore).Write(0xc000118000, {0x2, {0x0, 0x0, 0x0}, {0x0, 0x0}, {0x0, 0x0}, {0x0, 0x0, {0x0, 0x0}, 0x0, {0x0, 0x0}}, {0x0, 0x0}}, {0x0, 0x0, 0x0})
+	/go/pkg/mod/github.com/adlandh/sentry-zapcore/v2/sentry.go:130 +0x85
+example.com/app/worker.run(...)
+	/src/app/worker/run.go:8
+created by example.com/app/worker.Start in goroutine 1
+	/src/app/worker/start.go:15 +0x25`
+
+func TestStacktraceFromString(t *testing.T) {
+	t.Run("zap stack", func(t *testing.T) {
+		stacktrace := stacktraceFromString(zapStack, []string{"example.com/app", "main"})
+		require.NotNil(t, stacktrace)
+		require.Len(t, stacktrace.Frames, 3)
+
+		top := stacktrace.Frames[2]
+		require.Equal(t, "example.com/app/handler", top.Module)
+		require.Equal(t, "(*Server).Serve", top.Function)
+		require.Equal(t, "/src/app/handler/server.go", top.AbsPath)
+		require.Equal(t, 42, top.Lineno)
+		require.True(t, top.InApp)
+
+		require.Equal(t, "net/http", stacktrace.Frames[1].Module)
+		require.False(t, stacktrace.Frames[1].InApp)
+		require.Equal(t, "main", stacktrace.Frames[0].Module)
+		require.True(t, stacktrace.Frames[0].InApp)
+	})
+
+	t.Run("debug stack", func(t *testing.T) {
+		stacktrace := stacktraceFromString(debugStack, nil)
+		require.NotNil(t, stacktrace)
+		require.Len(t, stacktrace.Frames, 2)
+		require.Equal(t, "Start", stacktrace.Frames[0].Function)
+		require.Equal(t, 15, stacktrace.Frames[0].Lineno)
+		require.Equal(t, "run", stacktrace.Frames[1].Function)
+		require.Equal(t, "/src/app/worker/run.go", stacktrace.Frames[1].AbsPath)
+	})
+
+	t.Run("nothing left", func(t *testing.T) {
+		require.Nil(t, stacktraceFromString("", nil))
+		require.Nil(t, stacktraceFromString("go.uber.org/zap.(*Logger).Error\n\t/zap/logger.go:1", nil))
+	})
+}
+
+// requireStackTop asserts that the innermost frame left on the stack is
+// stacktest.Call, the caller of what was logged.
+func requireStackTop(t *testing.T, stacktrace *sentry.Stacktrace) {
+	t.Helper()
+
+	require.NotNil(t, stacktrace)
+	require.NotEmpty(t, stacktrace.Frames)
+
+	top := stacktrace.Frames[len(stacktrace.Frames)-1]
+	require.Equal(t, "github.com/adlandh/sentry-zapcore/v2/internal/stacktest", top.Module)
+	require.Equal(t, "Call", top.Function)
+}
+
+func TestEventStacktrace(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	logger := zap.New(NewSentryCore(context.Background(),
+		WithHub(hub),
+		WithStackTrace(),
+		WithEventLevel(zapcore.ErrorLevel),
+		WithInAppPrefixes("github.com/adlandh/sentry-zapcore"),
+	))
+
+	assertFrames := func(t *testing.T, stacktrace *sentry.Stacktrace) {
+		t.Helper()
+
+		requireStackTop(t, stacktrace)
+		require.True(t, stacktrace.Frames[len(stacktrace.Frames)-1].InApp)
+
+		for _, frame := range stacktrace.Frames {
+			require.NotContains(t, frame.Module, "go.uber.org/zap")
+		}
+	}
+
+	t.Run("message event", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		stacktest.Call(func() { logger.Error(message) })
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Len(t, event.Threads, 1)
+		assertFrames(t, event.Threads[0].Stacktrace)
+	})
+
+	t.Run("exception event", func(t *testing.T) {
+		message := gofakeit.Sentence()
+		stacktest.Call(func() { logger.Error(message, zap.Error(errors.New("boom"))) })
+
+		event, found := findEvent(transport.Events(), message)
+		require.True(t, found)
+		require.Len(t, event.Exception, 1)
+		assertFrames(t, event.Exception[0].Stacktrace)
+	})
+}
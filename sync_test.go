@@ -0,0 +1,176 @@
+package sentryzapcore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// stalledTransport never finishes flushing before its deadline.
+type stalledTransport struct {
+	transportMock
+}
+
+func (*stalledTransport) Flush(timeout time.Duration) bool {
+	time.Sleep(timeout)
+	return false
+}
+
+func (*stalledTransport) FlushWithContext(ctx context.Context) bool {
+	<-ctx.Done()
+	return false
+}
+
+func TestSyncFlushTimeout(t *testing.T) {
+	hub := newTestHub(t, &stalledTransport{})
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithFlushTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	err := core.Sync()
+	require.Less(t, time.Since(start), time.Second)
+
+	var flushErr *FlushError
+	require.ErrorAs(t, err, &flushErr)
+	require.Zero(t, flushErr.Undelivered)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSyncContextUndelivered(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+	hook := newBlockingHook()
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithAsync(16, 1, OverflowBlock),
+		WithBeforeEmit(hook.hook))
+	t.Cleanup(func() {
+		close(hook.release)
+		_ = core.Close()
+	})
+
+	logger := zap.New(core)
+	for range 3 {
+		logger.Error("stuck")
+	}
+
+	<-hook.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := core.SyncContext(ctx)
+
+	var flushErr *FlushError
+	require.ErrorAs(t, err, &flushErr)
+	require.Equal(t, 3, flushErr.Undelivered)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Contains(t, err.Error(), "3 queued entries undelivered")
+}
+
+func TestSyncContext(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithAsync(16, 1, OverflowBlock))
+	t.Cleanup(func() { _ = core.Close() })
+
+	zap.New(core).Error("delivered")
+
+	require.NoError(t, core.SyncContext(context.Background()))
+	require.Equal(t, []string{"delivered"}, logBodies(transport.Events()))
+}
+
+// deliveryHook records, when zap runs it, whether a message had already
+// reached the transport.
+type deliveryHook struct {
+	transport *transportMock
+	message   string
+	delivered bool
+	ran       bool
+}
+
+func (h *deliveryHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	_, h.delivered = findLog(h.transport.Events(), h.message)
+	h.ran = true
+}
+
+func TestFatalIsDeliveredBeforeExit(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	core := NewSentryCore(context.Background(), WithHub(hub), WithAsync(16, 1, OverflowBlock),
+		WithPanicFlushTimeout(time.Second))
+	t.Cleanup(func() { _ = core.Close() })
+
+	hook := &deliveryHook{transport: transport, message: gofakeit.Sentence()}
+	logger := zap.New(core, zap.WithFatalHook(hook))
+
+	logger.Error("queued")
+	logger.Fatal(hook.message)
+
+	require.True(t, hook.ran)
+	require.True(t, hook.delivered)
+	require.Equal(t, []string{"queued", hook.message}, logBodies(transport.Events()))
+}
+
+func TestPanicIsDeliveredBeforePanicking(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	hook := &deliveryHook{transport: transport, message: gofakeit.Sentence()}
+	logger := zap.New(NewSentryCore(context.Background(), WithHub(hub)), zap.WithPanicHook(hook))
+
+	logger.Panic(hook.message)
+
+	require.True(t, hook.ran)
+	require.True(t, hook.delivered)
+}
+
+func TestFatalFlushesTheContextHub(t *testing.T) {
+	transport := &transportMock{}
+	ctx := sentry.SetHubOnContext(context.Background(), newTestHub(t, transport))
+
+	hook := &deliveryHook{transport: transport, message: gofakeit.Sentence()}
+	logger := zap.New(NewSentryCore(ctx), zap.WithFatalHook(hook))
+
+	logger.Fatal(hook.message)
+
+	require.True(t, hook.ran)
+	require.True(t, hook.delivered)
+}
+
+func TestFatalFlushesTheEntryHub(t *testing.T) {
+	coreCtx := sentry.SetHubOnContext(context.Background(), newTestHub(t, &transportMock{}))
+
+	transport := &transportMock{}
+	ctx := sentry.SetHubOnContext(context.Background(), newTestHub(t, transport))
+
+	hook := &deliveryHook{transport: transport, message: gofakeit.Sentence()}
+	logger := zap.New(NewSentryCore(coreCtx), zap.WithFatalHook(hook))
+
+	logger.Fatal(hook.message, Context(ctx))
+
+	require.True(t, hook.ran)
+	require.True(t, hook.delivered)
+}
+
+func TestPanicIsNotRateLimited(t *testing.T) {
+	transport := &transportMock{}
+	hub := newTestHub(t, transport)
+
+	hook := &deliveryHook{transport: transport, message: gofakeit.Sentence()}
+	logger := zap.New(NewSentryCore(context.Background(), WithHub(hub), WithRateLimit(0, 1, RateLimitByLogger)),
+		zap.WithPanicHook(hook))
+
+	logger.Error(gofakeit.Sentence())
+	logger.Panic(hook.message)
+
+	require.True(t, hook.ran)
+	require.True(t, hook.delivered)
+}